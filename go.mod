@@ -3,6 +3,7 @@ module github.com/lizongying/go-crawler
 go 1.21.0
 
 require (
+	github.com/alicebob/miniredis/v2 v2.33.0
 	github.com/andybalholm/brotli v1.0.6
 	github.com/aws/aws-sdk-go-v2 v1.23.1
 	github.com/aws/aws-sdk-go-v2/credentials v1.16.4
@@ -11,6 +12,7 @@ require (
 	github.com/go-rod/stealth v0.4.9
 	github.com/go-sql-driver/mysql v1.7.1
 	github.com/google/uuid v1.4.0
+	github.com/klauspost/compress v1.16.7
 	github.com/lizongying/cron v1.0.0
 	github.com/lizongying/go-css v0.0.0-20230906104236-b58497123e0f
 	github.com/lizongying/go-gua64 v0.1.0
@@ -29,6 +31,7 @@ require (
 	go.mongodb.org/mongo-driver v1.13.0
 	go.uber.org/fx v1.20.1
 	golang.org/x/crypto v0.15.0
+	golang.org/x/net v0.17.0
 	golang.org/x/text v0.14.0
 	golang.org/x/time v0.4.0
 	gopkg.in/yaml.v3 v3.0.1
@@ -36,6 +39,7 @@ require (
 
 require (
 	github.com/PuerkitoBio/goquery v1.8.1 // indirect
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
 	github.com/andybalholm/cascadia v1.3.1 // indirect
 	github.com/antchfx/htmlquery v1.3.0 // indirect
 	github.com/antchfx/xpath v1.2.4 // indirect
@@ -55,7 +59,6 @@ require (
 	github.com/gaukas/godicttls v0.0.4 // indirect
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/golang/snappy v0.0.1 // indirect
-	github.com/klauspost/compress v1.16.7 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/montanaflynn/stats v0.0.0-20171201202039-1bf9dbcd8cbe // indirect
 	github.com/pierrec/lz4/v4 v4.1.15 // indirect
@@ -72,11 +75,11 @@ require (
 	github.com/ysmood/got v0.34.1 // indirect
 	github.com/ysmood/gson v0.7.3 // indirect
 	github.com/ysmood/leakless v0.8.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
 	go.uber.org/dig v1.17.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
 	go.uber.org/zap v1.23.0 // indirect
-	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/sync v0.2.0 // indirect
 	golang.org/x/sys v0.14.0 // indirect
 )
@@ -19,6 +19,21 @@ func (s *Spider) ParseOk(_ pkg.Context, response pkg.Response) (err error) {
 	return
 }
 
+func (s *Spider) ParseCaptures(_ pkg.Context, response pkg.Response) (err error) {
+	for _, capture := range response.Captures() {
+		s.logger.Info(capture.Method, capture.Url, capture.Status, len(capture.Body))
+	}
+	return
+}
+
+func (s *Spider) ParseActions(_ pkg.Context, response pkg.Response) (err error) {
+	s.logger.Info("eval results", response.EvalResults())
+	for _, file := range response.Files() {
+		s.logger.Info("file", file.GetStorePath())
+	}
+	return
+}
+
 // TestSohu go run cmd/testBrowserSpider/*.go -c example.yml -n test-browser -f TestSohu -m once
 func (s *Spider) TestSohu(ctx pkg.Context, _ string) (err error) {
 	err = s.YieldRequest(ctx, request.NewRequest().
@@ -67,6 +82,39 @@ func (s *Spider) TestAjax(ctx pkg.Context, _ string) (err error) {
 	return
 }
 
+// TestActions go run cmd/testBrowserSpider/*.go -c example.yml -n test-browser -f TestActions -m once
+func (s *Spider) TestActions(ctx pkg.Context, _ string) (err error) {
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl("https://www.sohu.com/").
+		SetClient(pkg.ClientBrowser).
+		SetActions(
+			pkg.Action{Type: pkg.ActionTypeWaitSelector, Selector: "body"},
+			pkg.Action{Type: pkg.ActionTypeScroll, Times: 3},
+			pkg.Action{Type: pkg.ActionTypeWaitIdle},
+			pkg.Action{Type: pkg.ActionTypeEval, Script: `() => document.title`},
+			pkg.Action{Type: pkg.ActionTypeScreenshot, FullPage: true},
+			pkg.Action{Type: pkg.ActionTypePdf},
+		).
+		SetCallBack(s.ParseActions))
+	if err != nil {
+		s.logger.Error(err)
+	}
+	return
+}
+
+// TestCaptures go run cmd/testBrowserSpider/*.go -c example.yml -n test-browser -f TestCaptures -m once
+func (s *Spider) TestCaptures(ctx pkg.Context, _ string) (err error) {
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl("https://www.sohu.com/").
+		SetClient(pkg.ClientBrowser).
+		SetCaptures(`\.json`, `/api/`).
+		SetCallBack(s.ParseCaptures))
+	if err != nil {
+		s.logger.Error(err)
+	}
+	return
+}
+
 func NewSpider(baseSpider pkg.Spider) (spider pkg.Spider, err error) {
 	spider = &Spider{
 		Spider: baseSpider,
@@ -60,6 +60,62 @@ func (s *Spider) TestBrotli(ctx pkg.Context, _ string) (err error) {
 	return
 }
 
+// TestDeflateZlib go run cmd/testCompressSpider/*.go -c dev.yml -n test-compress -f TestDeflateZlib -m once
+func (s *Spider) TestDeflateZlib(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteDeflateZlib(s.logger))
+
+	if err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlDeflateZlib)).
+		SetCallBack(s.ParseCompress)); err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestZstd go run cmd/testCompressSpider/*.go -c dev.yml -n test-compress -f TestZstd -m once
+func (s *Spider) TestZstd(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteZstd(s.logger))
+
+	if err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlZstd)).
+		SetCallBack(s.ParseCompress)); err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestMultiCompress go run cmd/testCompressSpider/*.go -c dev.yml -n test-compress -f TestMultiCompress -m once
+func (s *Spider) TestMultiCompress(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteMultiCompress(s.logger))
+
+	if err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlMultiCompress)).
+		SetCallBack(s.ParseCompress)); err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestCompressBomb go run cmd/testCompressSpider/*.go -c dev.yml -n test-compress -f TestCompressBomb -m once
+func (s *Spider) TestCompressBomb(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteCompressBomb(s.logger))
+
+	if err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlCompressBomb)).
+		SetCallBack(s.ParseCompress)); err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
 func NewSpider(baseSpider pkg.Spider) (spider pkg.Spider, err error) {
 	spider = &Spider{
 		Spider: baseSpider,
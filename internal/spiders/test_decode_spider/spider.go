@@ -14,6 +14,7 @@ type Spider struct {
 
 func (s *Spider) ParseDecode(_ pkg.Context, response pkg.Response) (err error) {
 	s.logger.Info("header", response.Headers())
+	s.logger.Info("charset", response.GetCharset())
 	s.logger.Info("body", response.BodyStr())
 	return
 }
@@ -78,6 +79,96 @@ func (s *Spider) TestBig5(ctx pkg.Context, _ string) (err error) {
 	return
 }
 
+// TestShiftJis go run cmd/testDecodeSpider/*.go -c dev.yml -n test-decode -f TestShiftJis -m once
+func (s *Spider) TestShiftJis(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteShiftJis(s.logger))
+
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlShiftJis)).
+		SetCallBack(s.ParseDecode))
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestEucKr go run cmd/testDecodeSpider/*.go -c dev.yml -n test-decode -f TestEucKr -m once
+func (s *Spider) TestEucKr(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteEucKr(s.logger))
+
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlEucKr)).
+		SetCallBack(s.ParseDecode))
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestWindows1251 go run cmd/testDecodeSpider/*.go -c dev.yml -n test-decode -f TestWindows1251 -m once
+func (s *Spider) TestWindows1251(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteWindows1251(s.logger))
+
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlWindows1251)).
+		SetCallBack(s.ParseDecode))
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestIso88592 go run cmd/testDecodeSpider/*.go -c dev.yml -n test-decode -f TestIso88592 -m once
+func (s *Spider) TestIso88592(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteIso88592(s.logger))
+
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlIso88592)).
+		SetCallBack(s.ParseDecode))
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestUtf16 go run cmd/testDecodeSpider/*.go -c dev.yml -n test-decode -f TestUtf16 -m once
+func (s *Spider) TestUtf16(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteUtf16(s.logger))
+
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlUtf16)).
+		SetCallBack(s.ParseDecode))
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
+// TestSniff go run cmd/testDecodeSpider/*.go -c dev.yml -n test-decode -f TestSniff -m once
+func (s *Spider) TestSniff(ctx pkg.Context, _ string) (err error) {
+	s.AddMockServerRoutes(mock_servers.NewRouteSniff(s.logger))
+
+	err = s.YieldRequest(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s%s", s.GetHost(), mock_servers.UrlSniff)).
+		SetCallBack(s.ParseDecode))
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return
+}
+
 func NewSpider(baseSpider pkg.Spider) (spider pkg.Spider, err error) {
 	spider = &Spider{
 		Spider: baseSpider,
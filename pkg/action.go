@@ -0,0 +1,29 @@
+package pkg
+
+import "time"
+
+// ActionType is the type of the action, which is run in the page by the browser client.
+type ActionType string
+
+const (
+	ActionTypeWaitSelector ActionType = "wait_selector" // wait until the element of the selector is visible
+	ActionTypeWaitIdle     ActionType = "wait_idle"     // wait until there's no network request for a while
+	ActionTypeClick        ActionType = "click"         // click the element of the selector
+	ActionTypeType         ActionType = "type"          // type the text into the element of the selector
+	ActionTypeScroll       ActionType = "scroll"        // scroll to the bottom, Times times
+	ActionTypeEval         ActionType = "eval"          // evaluate the script, the result is added to Response.EvalResults
+	ActionTypeScreenshot   ActionType = "screenshot"    // take a png screenshot, which is stored and added to Response.Files
+	ActionTypePdf          ActionType = "pdf"           // print the page to pdf, which is stored and added to Response.Files
+)
+
+// Action is a step of the request-level script, which is run in order after the page is navigated,
+// and before the response is produced. It works with the browser client only.
+type Action struct {
+	Type     ActionType    `json:"type"`
+	Selector string        `json:"selector,omitempty"`  // css selector
+	Text     string        `json:"text,omitempty"`      // for type
+	Script   string        `json:"script,omitempty"`    // for eval, a function or an expression, e.g. "() => document.title"
+	Times    uint8         `json:"times,omitempty"`     // for scroll, default 1
+	FullPage bool          `json:"full_page,omitempty"` // for screenshot
+	Timeout  time.Duration `json:"timeout,omitempty"`   // for the waits and the actions on an element, default the request timeout
+}
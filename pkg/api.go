@@ -7,4 +7,5 @@ type ReqJobStart struct {
 	Mode    JobMode `json:"mode,omitempty"`
 	Spec    string  `json:"spec,omitempty"`
 	Timeout uint32  `json:"timeout,omitempty"` // second
+	Node    string  `json:"node,omitempty"`    // the node to run the job in the cluster, the least-loaded one if empty
 }
@@ -38,6 +38,17 @@ func (h *RouteJobRun) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		req.Mode = pkg.JobModeOnce
 	}
 
+	if cluster := h.crawler.GetCluster(); cluster != nil {
+		node, jobId, err := cluster.RunJob(r.Context(), req)
+		if err != nil {
+			h.OutJson(w, 1, err.Error(), nil)
+			return
+		}
+
+		h.OutJson(w, 0, "", &job.Job{Id: jobId, Node: node})
+		return
+	}
+
 	c := context.Background()
 	if req.Timeout > 0 {
 		c, _ = context.WithTimeout(c, time.Duration(req.Timeout)*time.Second)
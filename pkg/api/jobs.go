@@ -19,6 +19,16 @@ func (h *RouteJobs) Pattern() string {
 }
 
 func (h *RouteJobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if cluster := h.crawler.GetCluster(); cluster != nil {
+		jobs, err := cluster.GetJobs(r.Context())
+		if err != nil {
+			h.OutJson(w, 1, err.Error(), nil)
+			return
+		}
+		h.OutJson(w, 0, "", jobs)
+		return
+	}
+
 	jobs := h.crawler.GetStatistics().GetJobs()
 	h.OutJson(w, 0, "", jobs)
 }
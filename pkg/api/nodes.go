@@ -19,6 +19,16 @@ func (h *RouteNodes) Pattern() string {
 }
 
 func (h *RouteNodes) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if cluster := h.crawler.GetCluster(); cluster != nil {
+		nodes, err := cluster.GetNodes(r.Context())
+		if err != nil {
+			h.OutJson(w, 1, err.Error(), nil)
+			return
+		}
+		h.OutJson(w, 0, "", nodes)
+		return
+	}
+
 	nodes := h.crawler.GetStatistics().GetNodes()
 	//for _, v := range nodes {
 	//	fmt.Println(v)
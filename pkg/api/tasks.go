@@ -19,6 +19,16 @@ func (h *RouteTasks) Pattern() string {
 }
 
 func (h *RouteTasks) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if cluster := h.crawler.GetCluster(); cluster != nil {
+		tasks, err := cluster.GetTasks(r.Context())
+		if err != nil {
+			h.OutJson(w, 1, err.Error(), nil)
+			return
+		}
+		h.OutJson(w, 0, "", tasks)
+		return
+	}
+
 	tasks := h.crawler.GetStatistics().GetTasks()
 	h.OutJson(w, 0, "", tasks)
 }
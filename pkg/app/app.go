@@ -12,6 +12,9 @@ import (
 	"github.com/lizongying/go-crawler/pkg/mock_servers"
 	"github.com/lizongying/go-crawler/pkg/spider"
 	"go.uber.org/fx"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 type App struct {
@@ -78,6 +81,16 @@ func (a *App) Run(crawlOptions ...pkg.CrawlOption) {
 
 			ctx := context.Background()
 			ctx, cancel := context.WithCancel(ctx)
+			if crawler.GetCheckpoint() != "" {
+				// stop the job gracefully on SIGINT/SIGTERM, so the checkpoint can be saved.
+				// a second signal kills the process.
+				var stop context.CancelFunc
+				ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				go func() {
+					<-ctx.Done()
+					stop()
+				}()
+			}
 			defer func() {
 				cancel()
 				if err = shutdowner.Shutdown(); err != nil {
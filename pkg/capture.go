@@ -0,0 +1,19 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Capture is a xhr/fetch response recorded by the browser client, whose url matches the captures of the request.
+type Capture struct {
+	Url     string      `json:"url"`
+	Method  string      `json:"method,omitempty"`
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    []byte      `json:"body,omitempty"`
+}
+
+func (c *Capture) UnmarshalBody(v any) error {
+	return json.Unmarshal(c.Body, v)
+}
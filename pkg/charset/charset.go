@@ -0,0 +1,183 @@
+package charset
+
+import (
+	"bytes"
+	htmlCharset "golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// prescanSize is the size of the beginning of the body, which is scanned for the declarations.
+const prescanSize = 1024
+
+// Source is where the charset is detected from.
+type Source string
+
+const (
+	SourceBom         Source = "bom"
+	SourceHeader      Source = "header"
+	SourceXml         Source = "xml"
+	SourceMeta        Source = "meta"
+	SourceSniff       Source = "sniff"
+	SourceUnsupported Source = "unsupported" // the declared charset isn't supported, the body is kept
+)
+
+var boms = []struct {
+	bom   []byte
+	label string
+}{
+	{[]byte{0xef, 0xbb, 0xbf}, "utf-8"},
+	{[]byte{0xfe, 0xff}, "utf-16be"},
+	{[]byte{0xff, 0xfe}, "utf-16le"},
+}
+
+var xmlDeclaration = regexp.MustCompile(`^\s*<\?xml\s[^>]*?encoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// Lookup returns the encoding by the label and its canonical name, it's nil if the label isn't supported.
+// The labels are looked up as the WHATWG encoding standard as browsers do, e.g. gb2312 is gbk,
+// then by the IANA names for the encodings not in the standard, e.g. hz-gb-2312.
+func Lookup(label string) (e encoding.Encoding, name string) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" {
+		return
+	}
+
+	if e, name = htmlCharset.Lookup(label); e != nil && e != encoding.Replacement {
+		return
+	}
+
+	e, err := ianaindex.IANA.Encoding(label)
+	if err != nil || e == nil {
+		return nil, ""
+	}
+	name, err = ianaindex.IANA.Name(e)
+	if err != nil {
+		name = label
+	}
+	name = strings.ToLower(name)
+	return
+}
+
+// Detect detects the charset of the body by the BOM, the charset of the Content-Type header,
+// the XML declaration, the <meta charset> or http-equiv of HTML, and the heuristic sniffing in order.
+// The encoding is nil if the declared charset isn't supported.
+func Detect(body []byte, contentType string) (e encoding.Encoding, name string, source Source) {
+	for _, b := range boms {
+		if bytes.HasPrefix(body, b.bom) {
+			e, name = Lookup(b.label)
+			return e, name, SourceBom
+		}
+	}
+
+	if label := charsetParam(contentType); label != "" {
+		if e, name = Lookup(label); e != nil {
+			return e, name, SourceHeader
+		}
+		return nil, strings.ToLower(label), SourceUnsupported
+	}
+
+	head := body
+	if len(head) > prescanSize {
+		head = head[:prescanSize]
+	}
+
+	if m := xmlDeclaration.FindSubmatch(head); m != nil {
+		if e, name = Lookup(string(m[1])); e != nil {
+			return e, name, SourceXml
+		}
+	}
+
+	if label := metaCharset(head); label != "" {
+		if e, name = Lookup(label); e != nil {
+			// a document decoded from bytes can't be utf-16, as the WHATWG encoding standard
+			if strings.HasPrefix(name, "utf-16") {
+				e, name = Lookup("utf-8")
+			}
+			return e, name, SourceMeta
+		}
+	}
+
+	e, name = Lookup(Sniff(body))
+	return e, name, SourceSniff
+}
+
+// Decode decodes the body to UTF-8 by the detected charset, the BOM is removed.
+// The body is returned as it is if it's UTF-8 or the charset isn't supported.
+func Decode(body []byte, contentType string) (decoded []byte, name string, source Source, err error) {
+	e, name, source := Detect(body, contentType)
+	if source == SourceBom {
+		for _, b := range boms {
+			if bytes.HasPrefix(body, b.bom) {
+				body = body[len(b.bom):]
+				break
+			}
+		}
+	}
+
+	if e == nil || name == "utf-8" {
+		decoded = body
+		return
+	}
+
+	decoded, err = e.NewDecoder().Bytes(body)
+	return
+}
+
+// IsText returns whether the body is text by the media type, the body is sniffed if the Content-Type is empty.
+// The binary bodies, e.g. images, aren't decoded.
+func IsText(contentType string, body []byte) bool {
+	if charsetParam(contentType) != "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+xml"),
+		strings.HasSuffix(mediaType, "+json"):
+		return true
+	}
+	switch mediaType {
+	case "application/json",
+		"application/javascript",
+		"application/x-javascript",
+		"application/ecmascript",
+		"application/xml":
+		return true
+	}
+	return false
+}
+
+func charsetParam(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return params["charset"]
+}
+
+// trimPartialRune removes the incomplete rune at the end of the body, e.g. the body is truncated.
+func trimPartialRune(body []byte) []byte {
+	for i := len(body) - 1; i >= 0 && i > len(body)-utf8.UTFMax; i-- {
+		b := body[i]
+		if b < utf8.RuneSelf {
+			break
+		}
+		if utf8.RuneStart(b) {
+			return body[:i]
+		}
+	}
+	return body
+}
@@ -0,0 +1,87 @@
+package charset
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"testing"
+)
+
+func encode(t *testing.T, e encoding.Encoding, s string) []byte {
+	b, err := e.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	const zh = "这是一个中文的网页，我们在这里测试字符集的检测。"
+	const tw = "這是一個中文的網頁，我們在這裡測試字元集的檢測。"
+	const ja = "これは日本語のページです。ここで文字コードの検出をテストします。"
+	const ko = "이것은 한국어 페이지입니다. 여기에서 문자 집합 감지를 테스트합니다."
+	const ru = "Это русская страница, здесь мы проверяем определение кодировки."
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		want        string
+		charset     string
+		source      Source
+	}{
+		{"bom", append([]byte{0xfe, 0xff}, encode(t, encoding.Nop, "")...), "text/html", "", "utf-16be", SourceBom},
+		{"header", encode(t, japanese.ShiftJIS, ja), "text/html; charset=Shift_JIS", ja, "shift_jis", SourceHeader},
+		{"unsupported", []byte("abc"), "text/html; charset=unknown", "abc", "unknown", SourceUnsupported},
+		{"meta", append([]byte(`<html><head><meta charset="euc-kr"></head><body>`), encode(t, korean.EUCKR, ko)...), "text/html",
+			`<html><head><meta charset="euc-kr"></head><body>` + ko, "euc-kr", SourceMeta},
+		{"http-equiv", append([]byte(`<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">`), encode(t, charmap.Windows1251, ru)...), "",
+			`<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">` + ru, "windows-1251", SourceMeta},
+		{"xml", append([]byte(`<?xml version="1.0" encoding="ISO-8859-2"?><a>`), encode(t, charmap.ISO8859_2, "Żółć")...), "application/xml",
+			`<?xml version="1.0" encoding="ISO-8859-2"?><a>Żółć`, "iso-8859-2", SourceXml},
+		{"utf-8", []byte(zh), "", zh, "utf-8", SourceSniff},
+		{"gbk", encode(t, simplifiedchinese.GBK, zh), "", zh, "gb18030", SourceSniff},
+		{"big5", encode(t, traditionalchinese.Big5, tw), "", tw, "big5", SourceSniff},
+		{"shift_jis", encode(t, japanese.ShiftJIS, ja), "", ja, "shift_jis", SourceSniff},
+		{"euc-kr", encode(t, korean.EUCKR, ko), "", ko, "euc-kr", SourceSniff},
+		{"windows-1251", encode(t, charmap.Windows1251, ru), "", ru, "windows-1251", SourceSniff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, name, source, err := Decode(tt.body, tt.contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.charset || source != tt.source {
+				t.Errorf("Decode() charset = %s from %s, want %s from %s", name, source, tt.charset, tt.source)
+			}
+			if string(decoded) != tt.want {
+				t.Errorf("Decode() = %q, want %q", decoded, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsText(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        []byte
+		want        bool
+	}{
+		{"text/html", nil, true},
+		{"application/json", nil, true},
+		{"application/rss+xml", nil, true},
+		{"image/png; charset=utf-8", nil, true},
+		{"image/png", nil, false},
+		{"", []byte("<html></html>"), true},
+		{"", []byte("\x89PNG\r\n\x1a\n"), false},
+	}
+	for _, tt := range tests {
+		if got := IsText(tt.contentType, tt.body); got != tt.want {
+			t.Errorf("IsText(%q, %q) = %v, want %v", tt.contentType, tt.body, got, tt.want)
+		}
+	}
+}
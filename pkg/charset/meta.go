@@ -0,0 +1,47 @@
+package charset
+
+import (
+	"bytes"
+	"golang.org/x/net/html"
+	"regexp"
+	"strings"
+)
+
+var contentCharset = regexp.MustCompile(`(?i)charset\s*=\s*["']?([^"';\s]+)`)
+
+// metaCharset returns the charset of <meta charset> or <meta http-equiv="Content-Type" content="...; charset=...">.
+func metaCharset(head []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(head))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !bytes.Equal(name, []byte("meta")) || !hasAttr {
+				continue
+			}
+
+			var httpEquiv, content string
+			for {
+				key, val, more := z.TagAttr()
+				switch string(key) {
+				case "charset":
+					return strings.TrimSpace(string(val))
+				case "http-equiv":
+					httpEquiv = strings.ToLower(strings.TrimSpace(string(val)))
+				case "content":
+					content = string(val)
+				}
+				if !more {
+					break
+				}
+			}
+			if httpEquiv == "content-type" {
+				if m := contentCharset.FindStringSubmatch(content); m != nil {
+					return m[1]
+				}
+			}
+		}
+	}
+}
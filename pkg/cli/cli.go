@@ -12,6 +12,7 @@ type Cli struct {
 	Args       string
 	Mode       string
 	Spec       string
+	Checkpoint string
 }
 
 func NewCli() (c *Cli, err error) {
@@ -21,6 +22,7 @@ func NewCli() (c *Cli, err error) {
 	argsPtr := flag.String("a", os.Getenv("CRAWLER_ARGS"), "args")
 	modePtr := flag.String("m", os.Getenv("CRAWLER_MODE"), "mode")
 	specPtr := flag.String("s", os.Getenv("CRAWLER_SPEC"), "spec")
+	checkpointPtr := flag.String("r", os.Getenv("CRAWLER_CHECKPOINT"), "checkpoint file, the memory scheduler resumes from it and saves to it on stop")
 
 	flag.Parse()
 
@@ -43,6 +45,7 @@ func NewCli() (c *Cli, err error) {
 		Args:       args,
 		Mode:       mode,
 		Spec:       *specPtr,
+		Checkpoint: *checkpointPtr,
 	}
 
 	return
@@ -0,0 +1,20 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Cluster coordinates the nodes sharing a redis.
+type Cluster interface {
+	GetId() string
+	IsLeader() bool
+	// GetNodes returns the statistics of the alive nodes, with their leader and load.
+	GetNodes(context.Context) ([]map[string]any, error)
+	GetJobs(context.Context) ([]json.RawMessage, error)
+	GetTasks(context.Context) ([]json.RawMessage, error)
+	// RunJob runs the job on the chosen node, or the least-loaded node having the spider.
+	RunJob(context.Context, ReqJobStart) (node string, id string, err error)
+	Start(context.Context) error
+	Stop(context.Context) error
+}
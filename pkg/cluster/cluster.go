@@ -0,0 +1,544 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/redis/go-redis/v9"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// commandTimeout is how long to wait for the reply of another node.
+const commandTimeout = 10 * time.Second
+
+// leaderScript takes the leadership if nobody holds it, or renews it if the node holds it.
+// KEYS: leader. ARGV: id, ttl in milliseconds.
+var leaderScript = redis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if not v then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+end
+if v == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// resignScript gives up the leadership if the node holds it.
+// KEYS: leader. ARGV: id.
+var resignScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// node is the snapshot of a node, refreshed by its heartbeats.
+type node struct {
+	Id      string          `json:"id"`
+	Load    int             `json:"load"` // the running tasks
+	Spiders []string        `json:"spiders"`
+	Node    json.RawMessage `json:"node"` // the statistics of the node
+	Jobs    json.RawMessage `json:"jobs"`
+	Tasks   json.RawMessage `json:"tasks"`
+}
+
+type command struct {
+	Reply string          `json:"reply"`
+	Job   pkg.ReqJobStart `json:"job"`
+}
+
+type reply struct {
+	Id    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Cluster registers the node in the redis with heartbeats, elects the leader,
+// and runs the jobs on the other nodes.
+//
+// keys:
+//
+//	<bot>:cluster:nodes               the alive nodes, scored by the last heartbeat
+//	<bot>:cluster:node:<id>           the snapshot of the node, expires without heartbeats
+//	<bot>:cluster:node:<id>:command   the jobs to run on the node
+//	<bot>:cluster:leader              the id of the leader
+type Cluster struct {
+	crawler pkg.Crawler
+	logger  pkg.Logger
+	redis   *redis.Client
+
+	id        string
+	prefix    string
+	heartbeat time.Duration
+	ttl       time.Duration
+	leader    atomic.Bool
+	running   sync.Map
+	cancel    context.CancelFunc
+}
+
+func (c *Cluster) GetId() string {
+	return c.id
+}
+func (c *Cluster) IsLeader() bool {
+	return c.leader.Load()
+}
+func (c *Cluster) nodesKey() string {
+	return fmt.Sprintf("%s:nodes", c.prefix)
+}
+func (c *Cluster) nodeKey(id string) string {
+	return fmt.Sprintf("%s:node:%s", c.prefix, id)
+}
+func (c *Cluster) commandKey(id string) string {
+	return fmt.Sprintf("%s:node:%s:command", c.prefix, id)
+}
+func (c *Cluster) leaderKey() string {
+	return fmt.Sprintf("%s:leader", c.prefix)
+}
+
+// taskChanged counts the running tasks as the load of the node.
+func (c *Cluster) taskChanged(ctx pkg.Context) (err error) {
+	switch ctx.GetTask().GetStatus() {
+	case pkg.TaskStatusRunning:
+		c.running.Store(ctx.GetTask().GetId(), struct{}{})
+	case pkg.TaskStatusSuccess, pkg.TaskStatusFailure:
+		c.running.Delete(ctx.GetTask().GetId())
+	}
+	return
+}
+func (c *Cluster) load() (load int) {
+	c.running.Range(func(_, _ any) bool {
+		load++
+		return true
+	})
+	return
+}
+
+func (c *Cluster) snapshot() (bs []byte, err error) {
+	n := node{
+		Id:   c.id,
+		Load: c.load(),
+	}
+	for _, v := range c.crawler.GetSpiders() {
+		n.Spiders = append(n.Spiders, v.Name())
+	}
+
+	statistics := c.crawler.GetStatistics()
+	// the statistics only know the local node
+	if nodes := statistics.GetNodes(); len(nodes) > 0 {
+		if n.Node, err = nodes[0].Marshal(); err != nil {
+			return
+		}
+	}
+	if n.Jobs, err = json.Marshal(statistics.GetJobs()); err != nil {
+		return
+	}
+	if n.Tasks, err = json.Marshal(statistics.GetTasks()); err != nil {
+		return
+	}
+	return json.Marshal(n)
+}
+
+// beat refreshes the snapshot of the node, and takes or renews the leadership.
+func (c *Cluster) beat(ctx context.Context) (err error) {
+	bs, err := c.snapshot()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	if _, err = c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, c.nodeKey(c.id), bs, c.ttl)
+		pipe.ZAdd(ctx, c.nodesKey(), redis.Z{Score: float64(now.UnixMilli()), Member: c.id})
+		pipe.ZRemRangeByScore(ctx, c.nodesKey(), "-inf", fmt.Sprint(now.Add(-c.ttl).UnixMilli()))
+		return nil
+	}); err != nil {
+		return
+	}
+
+	leader, err := leaderScript.Run(ctx, c.redis, []string{c.leaderKey()}, c.id, c.ttl.Milliseconds()).Bool()
+	if err != nil {
+		return
+	}
+	if c.leader.Swap(leader) != leader {
+		if leader {
+			c.logger.Info("the node becomes the leader", c.id)
+		} else {
+			c.logger.Info("the node isn't the leader anymore", c.id)
+		}
+	}
+	return
+}
+
+func (c *Cluster) handleHeartbeat(ctx context.Context) {
+	ticker := time.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.beat(ctx); err != nil && ctx.Err() == nil {
+				// the node can't tell if it's still the leader
+				c.leader.Store(false)
+				c.logger.Warn("heartbeat", err)
+			}
+		}
+	}
+}
+
+// nodes returns the alive nodes.
+func (c *Cluster) nodes(ctx context.Context) (nodes []*node, err error) {
+	ids, err := c.redis.ZRangeByScore(ctx, c.nodesKey(), &redis.ZRangeBy{
+		Min: fmt.Sprint(time.Now().Add(-c.ttl).UnixMilli()),
+		Max: "+inf",
+	}).Result()
+	if err != nil || len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = c.nodeKey(id)
+	}
+	values, err := c.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return
+	}
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			// expired
+			continue
+		}
+		n := new(node)
+		if e := json.Unmarshal([]byte(s), n); e != nil {
+			c.logger.Warn(e)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return
+}
+
+func (c *Cluster) GetNodes(ctx context.Context) (nodes []map[string]any, err error) {
+	ns, err := c.nodes(ctx)
+	if err != nil {
+		return
+	}
+	leader, err := c.redis.Get(ctx, c.leaderKey()).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return
+	}
+	err = nil
+
+	for _, n := range ns {
+		m := make(map[string]any)
+		if len(n.Node) > 0 {
+			if err = json.Unmarshal(n.Node, &m); err != nil {
+				return
+			}
+		}
+		m["id"] = n.Id
+		m["leader"] = n.Id == leader
+		m["load"] = n.Load
+		m["spiders"] = n.Spiders
+		nodes = append(nodes, m)
+	}
+	return
+}
+func (c *Cluster) GetJobs(ctx context.Context) (jobs []json.RawMessage, err error) {
+	ns, err := c.nodes(ctx)
+	if err != nil {
+		return
+	}
+	for _, n := range ns {
+		var l []json.RawMessage
+		if err = json.Unmarshal(n.Jobs, &l); err != nil {
+			return
+		}
+		jobs = append(jobs, l...)
+	}
+	return
+}
+func (c *Cluster) GetTasks(ctx context.Context) (tasks []json.RawMessage, err error) {
+	ns, err := c.nodes(ctx)
+	if err != nil {
+		return
+	}
+	for _, n := range ns {
+		var l []json.RawMessage
+		if err = json.Unmarshal(n.Tasks, &l); err != nil {
+			return
+		}
+		tasks = append(tasks, l...)
+	}
+	return
+}
+
+// spiderNodes returns the nodes having the spider, sorted by the id.
+func spiderNodes(nodes []*node, spider string) (candidates []*node, err error) {
+	for _, n := range nodes {
+		for _, v := range n.Spiders {
+			if v == spider {
+				candidates = append(candidates, n)
+				break
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		err = errors.New("no node has the spider")
+		return
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Id < candidates[j].Id
+	})
+	return
+}
+
+// leastLoaded returns the id of the node having the spider with the fewest running tasks.
+func leastLoaded(nodes []*node, spider string) (id string, err error) {
+	candidates, err := spiderNodes(nodes, spider)
+	if err != nil {
+		return
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Load != candidates[j].Load {
+			return candidates[i].Load < candidates[j].Load
+		}
+		return candidates[i].Id < candidates[j].Id
+	})
+	id = candidates[0].Id
+	return
+}
+
+func (c *Cluster) RunJob(ctx context.Context, req pkg.ReqJobStart) (node string, id string, err error) {
+	nodes, err := c.nodes(ctx)
+	if err != nil {
+		return
+	}
+
+	if req.Mode == pkg.JobModeCron {
+		return c.runCronJob(ctx, nodes, req)
+	}
+
+	node = req.Node
+	if node == "" {
+		if node, err = leastLoaded(nodes, req.Name); err != nil {
+			return
+		}
+	} else {
+		alive := false
+		for _, n := range nodes {
+			if n.Id == node {
+				alive = true
+				break
+			}
+		}
+		if !alive {
+			err = errors.New("the node isn't alive")
+			return
+		}
+	}
+
+	id, err = c.start(ctx, node, req)
+	return
+}
+
+// runCronJob schedules the cron job on every node having the spider, and it fires only on the leader at the time,
+// so the job goes on after the leadership moves to another node.
+// The nodes and the ids of the jobs are joined by commas.
+func (c *Cluster) runCronJob(ctx context.Context, nodes []*node, req pkg.ReqJobStart) (node string, id string, err error) {
+	if req.Node != "" {
+		err = errors.New("the cron job is scheduled on every node having the spider")
+		return
+	}
+
+	candidates, err := spiderNodes(nodes, req.Name)
+	if err != nil {
+		return
+	}
+
+	var started, ids []string
+	for _, n := range candidates {
+		jobId, e := c.start(ctx, n.Id, req)
+		if e != nil {
+			c.logger.Warn("schedule the cron job on", n.Id, e)
+			err = e
+			continue
+		}
+		started = append(started, n.Id)
+		ids = append(ids, jobId)
+	}
+	if len(started) == 0 {
+		return
+	}
+
+	err = nil
+	node = strings.Join(started, ",")
+	id = strings.Join(ids, ",")
+	return
+}
+
+// start runs the job on the node, here or by a command.
+func (c *Cluster) start(ctx context.Context, node string, req pkg.ReqJobStart) (id string, err error) {
+	if node == c.id {
+		return c.runJob(req)
+	}
+
+	return c.send(ctx, node, req)
+}
+
+// runJob runs the job on the node.
+func (c *Cluster) runJob(req pkg.ReqJobStart) (id string, err error) {
+	ctx := context.Background()
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		time.AfterFunc(time.Duration(req.Timeout)*time.Second, cancel)
+	}
+
+	return c.crawler.RunJob(ctx, req.Name, req.Func, req.Args, req.Mode, req.Spec)
+}
+
+// send sends the job to the node, and waits for the reply.
+func (c *Cluster) send(ctx context.Context, node string, req pkg.ReqJobStart) (id string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := command{
+		Reply: fmt.Sprintf("%s:reply:%s", c.prefix, c.crawler.NextId()),
+		Job:   req,
+	}
+	bs, err := json.Marshal(cmd)
+	if err != nil {
+		return
+	}
+	if _, err = c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, c.commandKey(node), bs)
+		pipe.Expire(ctx, c.commandKey(node), time.Minute)
+		return nil
+	}); err != nil {
+		return
+	}
+
+	r, err := c.redis.BLPop(ctx, commandTimeout, cmd.Reply).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			err = errors.New("the node didn't reply")
+		}
+		return
+	}
+
+	var rep reply
+	if err = json.Unmarshal([]byte(r[1]), &rep); err != nil {
+		return
+	}
+	if rep.Error != "" {
+		err = errors.New(rep.Error)
+		return
+	}
+	id = rep.Id
+	return
+}
+
+// handleCommands runs the jobs sent by the other nodes.
+func (c *Cluster) handleCommands(ctx context.Context) {
+	for {
+		r, err := c.redis.BLPop(ctx, c.heartbeat, c.commandKey(c.id)).Result()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if !errors.Is(err, redis.Nil) {
+				c.logger.Warn(err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+
+		var cmd command
+		if err = json.Unmarshal([]byte(r[1]), &cmd); err != nil {
+			c.logger.Warn(err)
+			continue
+		}
+
+		c.logger.Info("run the job from the cluster", cmd.Job.Name)
+		var rep reply
+		if rep.Id, err = c.runJob(cmd.Job); err != nil {
+			rep.Error = err.Error()
+		}
+		bs, _ := json.Marshal(rep)
+		if _, err = c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.RPush(ctx, cmd.Reply, bs)
+			pipe.Expire(ctx, cmd.Reply, time.Minute)
+			return nil
+		}); err != nil {
+			c.logger.Warn(err)
+		}
+	}
+}
+
+func (c *Cluster) Start(ctx context.Context) (err error) {
+	if c.redis == nil {
+		err = errors.New(`redis nil. please check if "redis_enable: false"`)
+		return
+	}
+
+	c.id = c.crawler.GetContext().GetCrawler().GetId()
+	c.crawler.GetSignal().RegisterTaskChanged(c.taskChanged)
+
+	if err = c.beat(ctx); err != nil {
+		return
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.handleHeartbeat(ctx)
+	go c.handleCommands(ctx)
+
+	c.logger.Info("the node joins the cluster", c.id)
+	return
+}
+
+// Stop leaves the cluster, and gives up the leadership.
+func (c *Cluster) Stop(ctx context.Context) (err error) {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	c.leader.Store(false)
+
+	if _, err = c.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, c.nodeKey(c.id))
+		pipe.ZRem(ctx, c.nodesKey(), c.id)
+		return nil
+	}); err != nil {
+		return
+	}
+	err = resignScript.Run(ctx, c.redis, []string{c.leaderKey()}, c.id).Err()
+	return
+}
+
+func (c *Cluster) FromCrawler(crawler pkg.Crawler) pkg.Cluster {
+	if c == nil {
+		return new(Cluster).FromCrawler(crawler)
+	}
+
+	config := crawler.GetConfig()
+	c.crawler = crawler
+	c.logger = crawler.GetLogger()
+	c.redis = crawler.GetRedis()
+	c.prefix = fmt.Sprintf("%s:cluster", config.GetBotName())
+	c.heartbeat = config.GetClusterHeartbeat()
+	c.ttl = c.heartbeat * 3
+	return c
+}
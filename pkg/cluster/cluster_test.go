@@ -0,0 +1,45 @@
+package cluster
+
+import "testing"
+
+func TestSpiderNodes(t *testing.T) {
+	nodes := []*node{
+		{Id: "3", Spiders: []string{"a"}},
+		{Id: "2", Spiders: []string{"b"}},
+		{Id: "1", Spiders: []string{"a", "b"}},
+	}
+
+	candidates, err := spiderNodes(nodes, "a")
+	if err != nil || len(candidates) != 2 || candidates[0].Id != "1" || candidates[1].Id != "3" {
+		t.Errorf("spiderNodes(a) = %v, %v", candidates, err)
+	}
+	if _, err = spiderNodes(nodes, "c"); err == nil {
+		t.Error("want an error for the spider no node has")
+	}
+}
+
+func TestLeastLoaded(t *testing.T) {
+	nodes := []*node{
+		{Id: "3", Load: 1, Spiders: []string{"a", "b"}},
+		{Id: "2", Load: 0, Spiders: []string{"b"}},
+		{Id: "1", Load: 1, Spiders: []string{"a"}},
+	}
+
+	for _, tt := range []struct {
+		spider string
+		id     string
+		err    bool
+	}{
+		{"a", "1", false},
+		{"b", "2", false},
+		{"c", "", true},
+	} {
+		id, err := leastLoaded(nodes, tt.spider)
+		if (err != nil) != tt.err {
+			t.Fatalf("leastLoaded(%s) err %v", tt.spider, err)
+		}
+		if id != tt.id {
+			t.Errorf("leastLoaded(%s) = %s, want %s", tt.spider, id, tt.id)
+		}
+	}
+}
@@ -9,19 +9,67 @@ type Config interface {
 	GetEnv() string
 	GetBotName() string
 	GetProxy() *url.URL
+	GetProxyList() []string
+	GetProxyFile() string
+	GetProxyRedisKey() string
+	GetProxyStrategy() ProxyStrategy
+	GetProxyBanStatusCodes() []int
+	GetProxyBanTime() time.Duration
+	GetProxyMaxFailures() uint8
 	GetHttpProto() string
 	GetRequestTimeout() time.Duration
 	GetEnableJa3() bool
 	GetEnablePriorityQueue() bool
 	GetReferrerPolicy() ReferrerPolicy
+	GetRobotsTxtUserAgent() string
 	GetUrlLengthLimit() int
 	GetRedirectMaxTimes() uint8
 	GetRetryMaxTimes() uint8
+	GetRetryBackoff() RetryBackoff
+	GetRetryDelay() time.Duration
+	GetRetryMaxDelay() time.Duration
+	GetRetryAfter() bool
+	GetRetryErrors() []RetryErrorClass
 
 	GetEnableStatsMiddleware() bool
 	GetEnableDumpMiddleware() bool
 	GetEnableProxyMiddleware() bool
 	GetEnableRobotsTxtMiddleware() bool
+	GetEnableHttpCacheMiddleware() bool
+	GetHttpCacheStorage() HttpCacheStorage
+	GetHttpCacheDir() string
+	GetHttpCacheTtl() time.Duration
+	GetHttpCacheIgnoreStatusCodes() []int
+	GetHttpCachePolicy() HttpCachePolicy
+	GetCookieStorage() CookieStorage
+	GetCookieDir() string
+	GetCompressMaxRatio() uint
+	GetBrowserPoolSize() uint8
+	GetBrowserHeadless() bool
+	GetBrowserFlags() []string
+	GetBrowserBlock() []string
+	GetBrowserIdleTimeout() time.Duration
+	GetBrowserRemote() string
+	GetRedisQueueReliable() bool
+	GetRedisQueueLease() time.Duration
+	GetRedisQueueReclaimInterval() time.Duration
+	GetRedisQueueMaxReclaims() uint
+	GetKafkaQueueGroupId() string
+	GetKafkaQueueStartOffset() KafkaStartOffset
+	GetKafkaQueueCommitInterval() time.Duration
+	GetKafkaQueueCommitBatch() int
+	GetKafkaQueueDeadLetter() bool
+	GetClusterEnable() bool
+	GetClusterHeartbeat() time.Duration
+	GetPipelineBatchSize() int
+	GetPipelineBatchInterval() time.Duration
+	GetSqlitePipelineKey() string
+	GetSqlitePipelineCreateTable() bool
+	GetEnableSessionMiddleware() bool
+	GetEnableAutoThrottleMiddleware() bool
+	GetAutoThrottleTargetConcurrency() float64
+	GetAutoThrottleMinDelay() time.Duration
+	GetAutoThrottleMaxDelay() time.Duration
 	GetEnableFilterMiddleware() bool
 	GetEnableFileMiddleware() bool
 	GetEnableImageMiddleware() bool
@@ -51,8 +99,21 @@ type Config interface {
 
 	GetRequestConcurrency() uint8
 	GetRequestInterval() uint
+	GetRequestMaxBodySize() int64
+	GetRequestSlot() SlotType
+	GetRequestSlots() map[string]SlotOptions
+	GetRequestMaxIdleConns() int
+	GetRequestMaxIdleConnsPerHost() int
+	GetRequestMaxConnsPerHost() int
+	GetRequestIdleConnTimeout() time.Duration
 	GetOkHttpCodes() []int
 	GetFilter() FilterType
+	GetFilterBloomCapacity() uint
+	GetFilterBloomFalsePositiveRate() float64
+	GetRequestFingerprintFilter() bool
+	GetRequestFingerprintIgnoreParams() []string
+	GetRequestFingerprintHeaders() []string
+	GetRequestFingerprintBody() bool
 	GetScheduler() SchedulerType
 	ApiAccessKey() string
 	SetApiAccessKey(accessKey string)
@@ -37,6 +37,10 @@ const defaultEnableDecodeMiddleware = true
 const defaultEnableRedirectMiddleware = true
 const defaultRedirectMaxTimes = uint8(1)
 const defaultRetryMaxTimes = uint8(10)
+const defaultRetryBackoff = pkg.RetryBackoffExponential
+const defaultRetryDelay = uint(1000)     // millisecond
+const defaultRetryMaxDelay = uint(60000) // millisecond
+const defaultRetryAfter = true
 const defaultEnableChromeMiddleware = true
 const defaultEnableDeviceMiddleware = false
 const defaultEnableDumpMiddleware = true
@@ -47,6 +51,9 @@ const defaultEnableHttpMiddleware = true
 const defaultEnableProxyMiddleware = true
 const defaultEnableRobotsTxtMiddleware = false
 const defaultEnableRecordErrorMiddleware = false
+const defaultEnableHttpCacheMiddleware = false
+const defaultEnableAutoThrottleMiddleware = false
+const defaultEnableSessionMiddleware = false
 const defaultEnableDumpPipeline = true
 const defaultEnableFilePipeline = true
 const defaultEnableImagePipeline = true
@@ -60,9 +67,58 @@ const defaultEnableKafkaPipeline = false
 const defaultRequestConcurrency = uint8(1) // should bigger than 1
 const defaultRequestInterval = uint(1000)  // millisecond
 const defaultRequestTimeout = uint(60)     //second
+const defaultRequestMaxIdleConns = 1000
+const defaultRequestMaxIdleConnsPerHost = 1000
+const defaultRequestMaxConnsPerHost = 1000
+const defaultRequestIdleConnTimeout = uint(180) //second
 const defaultFilterType = pkg.FilterMemory
+const defaultFilterBloomCapacity = uint(1000000)
+const defaultFilterBloomFalsePositiveRate = 0.001
+const defaultRequestFingerprintFilter = false
+const defaultRequestFingerprintBody = true
 const defaultSchedulerType = pkg.SchedulerMemory
 const defaultLogLongFile = true
+const defaultProxyStrategy = pkg.ProxyStrategyRoundRobin
+const defaultProxyBanTime = uint(300) // second
+const defaultProxyMaxFailures = uint8(3)
+const defaultHttpCacheStorage = pkg.HttpCacheStorageFile
+const defaultHttpCacheDir = ".cache/http"
+const defaultHttpCachePolicy = pkg.HttpCachePolicyDummy
+const defaultCookieDir = ".cache/cookies"
+const defaultCompressMaxRatio = uint(100)
+const defaultBrowserPoolSize = uint8(2)
+const defaultBrowserHeadless = true
+const defaultBrowserIdleTimeout = uint(300) // second
+const defaultRedisQueueLease = uint(60)
+const defaultRedisQueueReclaimInterval = uint(10)
+const defaultRedisQueueMaxReclaims = uint(3)
+const defaultKafkaQueueStartOffset = pkg.KafkaStartOffsetFirst
+const defaultKafkaQueueCommitInterval = uint(1000)
+const defaultKafkaQueueCommitBatch = uint(100)
+const defaultKafkaQueueDeadLetter = true
+const defaultClusterHeartbeat = uint(5)
+const defaultPipelineBatchSize = uint(100)
+const defaultPipelineBatchInterval = uint(1000)
+const defaultSqlitePipelineKey = "id"
+const defaultAutoThrottleTargetConcurrency = 1.0
+const defaultAutoThrottleMaxDelay = uint(60000) // millisecond
+
+var defaultBrowserBlock = []string{"image", "media"}
+var defaultHttpCacheIgnoreStatusCodes = []int{408, 429, 500, 502, 503, 504}
+var defaultRequestFingerprintIgnoreParams = []string{"utm_*", "gclid", "fbclid", "msclkid", "spm"}
+var defaultRetryErrors = []string{
+	string(pkg.RetryErrorTimeout),
+	string(pkg.RetryErrorReset),
+	string(pkg.RetryErrorRefused),
+	string(pkg.RetryErrorDns),
+	string(pkg.RetryErrorEof),
+}
+
+// Slot overrides the rate of a request slot, e.g. a host.
+type Slot struct {
+	Interval    uint  `yaml:"interval" json:"-"` // millisecond
+	Concurrency uint8 `yaml:"concurrency" json:"-"`
+}
 
 type Store struct {
 	Name     string `yaml:"name" json:"-"`
@@ -118,8 +174,69 @@ type Config struct {
 		Level    *string `yaml:"level" json:"-"`
 	} `yaml:"log" json:"-"`
 	Proxy struct {
-		Example string `yaml:"example" json:"-"`
+		Example        string   `yaml:"example" json:"-"`
+		List           []string `yaml:"list" json:"-"`      // proxy pool
+		File           string   `yaml:"file" json:"-"`      // proxy pool, one proxy per line
+		RedisKey       string   `yaml:"redis_key" json:"-"` // proxy pool, redis set
+		Strategy       string   `yaml:"strategy" json:"-"`  // round_robin/random/sticky
+		BanStatusCodes []int    `yaml:"ban_status_codes" json:"-"`
+		BanTime        *uint    `yaml:"ban_time" json:"-"` // second
+		MaxFailures    *uint8   `yaml:"max_failures" json:"-"`
 	} `yaml:"proxy" json:"-"`
+	EnableSessionMiddleware      *bool `yaml:"enable_session_middleware,omitempty" json:"enable_session_middleware"`
+	EnableAutoThrottleMiddleware *bool `yaml:"enable_auto_throttle_middleware,omitempty" json:"enable_auto_throttle_middleware"`
+	AutoThrottle                 struct {
+		TargetConcurrency *float64 `yaml:"target_concurrency" json:"-"`
+		MinDelay          uint     `yaml:"min_delay" json:"-"` // millisecond
+		MaxDelay          *uint    `yaml:"max_delay" json:"-"` // millisecond
+	} `yaml:"auto_throttle" json:"-"`
+	HttpCache struct {
+		Storage           string `yaml:"storage" json:"-"` // file/sqlite
+		Dir               string `yaml:"dir" json:"-"`     // for the file storage
+		Ttl               uint   `yaml:"ttl" json:"-"`     // second, 0 means never expire
+		IgnoreStatusCodes []int  `yaml:"ignore_status_codes" json:"-"`
+		Policy            string `yaml:"policy" json:"-"` // dummy/rfc9111
+	} `yaml:"http_cache" json:"-"`
+	Cookie struct {
+		Storage string `yaml:"storage" json:"-"` // file/redis, the cookies aren't persisted if empty
+		Dir     string `yaml:"dir" json:"-"`     // for the file storage
+	} `yaml:"cookie" json:"-"`
+	Compress struct {
+		MaxRatio *uint `yaml:"max_ratio" json:"-"` // max ratio of the decompressed size to the compressed size, 0 means unlimited
+	} `yaml:"compress" json:"-"`
+	Browser struct {
+		PoolSize    *uint8   `yaml:"pool_size" json:"-"`
+		Headless    *bool    `yaml:"headless" json:"-"`
+		Flags       []string `yaml:"flags" json:"-"`        // extra flags to launch, e.g. --window-size=1920,1080
+		Block       []string `yaml:"block" json:"-"`        // resource types to block, e.g. image/media/font/stylesheet
+		IdleTimeout *uint    `yaml:"idle_timeout" json:"-"` // second, the idle browser is closed, 0 means never
+		Remote      string   `yaml:"remote" json:"-"`       // the cdp endpoint to connect instead of launching, e.g. ws://127.0.0.1:9222
+	} `yaml:"browser" json:"-"`
+	RedisQueue struct {
+		Reliable        bool  `yaml:"reliable" json:"-"`         // keep the popped requests in flight until they are acknowledged
+		Lease           *uint `yaml:"lease" json:"-"`            // second, the in-flight request is reclaimed if the lease expires
+		ReclaimInterval *uint `yaml:"reclaim_interval" json:"-"` // second
+		MaxReclaims     *uint `yaml:"max_reclaims" json:"-"`     // the request reclaimed more times is moved to the dead list
+	} `yaml:"redis_queue" json:"-"`
+	KafkaQueue struct {
+		GroupId        string `yaml:"group_id" json:"-"`        // the bot name if empty
+		StartOffset    string `yaml:"start_offset" json:"-"`    // first/last, where the group starts without committed offsets
+		CommitInterval *uint  `yaml:"commit_interval" json:"-"` // millisecond
+		CommitBatch    *uint  `yaml:"commit_batch" json:"-"`    // the offsets are committed once so many messages are done
+		DeadLetter     *bool  `yaml:"dead_letter" json:"-"`     // send the failed requests to the "<topic>-dead-letter" topic
+	} `yaml:"kafka_queue" json:"-"`
+	Cluster struct {
+		Enable    bool  `yaml:"enable" json:"-"`    // the nodes sharing the redis coordinate with each other
+		Heartbeat *uint `yaml:"heartbeat" json:"-"` // second, the node is gone without heartbeats for three times
+	} `yaml:"cluster" json:"-"`
+	PipelineBatch struct {
+		Size     *uint `yaml:"size" json:"-"`     // the items are written once so many are buffered, 1 means no batching
+		Interval *uint `yaml:"interval" json:"-"` // millisecond, the buffered items are written at the latest
+	} `yaml:"pipeline_batch" json:"-"`
+	SqlitePipeline struct {
+		Key         string `yaml:"key" json:"-"`          // the conflict column of the upsert
+		CreateTable bool   `yaml:"create_table" json:"-"` // create the tables and add the missing columns from the items
+	} `yaml:"sqlite_pipeline" json:"-"`
 	Request struct {
 		Concurrency   *uint8 `yaml:"concurrency" json:"-"`
 		Interval      *uint  `yaml:"interval" json:"-"`
@@ -127,6 +244,21 @@ type Config struct {
 		OkHttpCodes   []int  `yaml:"ok_http_codes" json:"-"`
 		RetryMaxTimes *uint8 `yaml:"retry_max_times" json:"-"`
 		HttpProto     string `yaml:"http_proto" json:"-"`
+		MaxBodySize   int64  `yaml:"max_body_size" json:"-"` // byte, 0 means unlimited
+		// backoff of the retry middleware
+		RetryBackoff  string   `yaml:"retry_backoff" json:"-"`   // fixed/exponential/jitter
+		RetryDelay    *uint    `yaml:"retry_delay" json:"-"`     // millisecond
+		RetryMaxDelay *uint    `yaml:"retry_max_delay" json:"-"` // millisecond
+		RetryAfter    *bool    `yaml:"retry_after" json:"-"`     // respect Retry-After of 429/503
+		RetryErrors   []string `yaml:"retry_errors" json:"-"`    // transport errors to retry
+		// the slots of the requests without slot, and the slot options
+		Slot  string           `yaml:"slot" json:"-"` // host/domain, the "*" slot if empty
+		Slots map[string]*Slot `yaml:"slots" json:"-"`
+		// connection pool of the http client
+		MaxIdleConns        *int  `yaml:"max_idle_conns" json:"-"`
+		MaxIdleConnsPerHost *int  `yaml:"max_idle_conns_per_host" json:"-"`
+		MaxConnsPerHost     *int  `yaml:"max_conns_per_host" json:"-"`
+		IdleConnTimeout     *uint `yaml:"idle_conn_timeout" json:"-"`
 	} `yaml:"request" json:"-"`
 	Api struct {
 		Enable    *bool  `yaml:"enable,omitempty" json:"enable"`
@@ -142,10 +274,21 @@ type Config struct {
 	CloseReason struct {
 		QueueTimeout *uint8 `yaml:"client_auth,omitempty" json:"client_auth"`
 	} `yaml:"close_reason" json:"close_reason"`
+	FilterBloom struct {
+		Capacity          *uint    `yaml:"capacity,omitempty" json:"capacity"`
+		FalsePositiveRate *float64 `yaml:"false_positive_rate,omitempty" json:"false_positive_rate"`
+	} `yaml:"filter_bloom" json:"filter_bloom"`
+	RequestFingerprint struct {
+		Filter       *bool    `yaml:"filter,omitempty" json:"filter"`
+		IgnoreParams []string `yaml:"ignore_params,omitempty" json:"ignore_params"`
+		Headers      []string `yaml:"headers,omitempty" json:"headers"`
+		Body         *bool    `yaml:"body,omitempty" json:"body"`
+	} `yaml:"request_fingerprint" json:"request_fingerprint"`
 	EnableJa3                   *bool   `yaml:"enable_ja3,omitempty" json:"enable_ja3"`
 	EnablePriorityQueue         *bool   `yaml:"enable_priority_queue,omitempty" json:"enable_priority_queue"`
 	EnableReferrerMiddleware    *bool   `yaml:"enable_referrer_middleware,omitempty" json:"enable_referrer_middleware"`
 	ReferrerPolicy              *string `yaml:"referrer_policy,omitempty" json:"referrer_policy"`
+	RobotsTxtUserAgent          *string `yaml:"robots_txt_user_agent,omitempty" json:"robots_txt_user_agent"`
 	EnableHttpAuthMiddleware    *bool   `yaml:"enable_http_auth_middleware,omitempty" json:"enable_http_auth_middleware"`
 	EnableCookieMiddleware      *bool   `yaml:"enable_cookie_middleware,omitempty" json:"enable_cookie_middleware"`
 	EnableStatsMiddleware       *bool   `yaml:"enable_stats_middleware,omitempty" json:"enable_stats_middleware"`
@@ -168,6 +311,7 @@ type Config struct {
 	EnableProxyMiddleware       *bool   `yaml:"enable_proxy_middleware,omitempty" json:"enable_proxy_middleware"`
 	EnableRobotsTxtMiddleware   *bool   `yaml:"enable_robots_txt_middleware,omitempty" json:"enable_robots_txt_middleware"`
 	EnableRecordErrorMiddleware *bool   `yaml:"enable_record_error_middleware,omitempty" json:"enable_record_error_middleware"`
+	EnableHttpCacheMiddleware   *bool   `yaml:"enable_http_cache_middleware,omitempty" json:"enable_http_cache_middleware"`
 	EnableDumpPipeline          *bool   `yaml:"enable_dump_pipeline,omitempty" json:"enable_dump_pipeline"`
 	EnableFilePipeline          *bool   `yaml:"enable_file_pipeline,omitempty" json:"enable_file_pipeline"`
 	EnableImagePipeline         *bool   `yaml:"enable_image_pipeline,omitempty" json:"enable_image_pipeline"`
@@ -214,6 +358,297 @@ func (c *Config) GetProxy() *url.URL {
 	return nil
 }
 
+func (c *Config) GetProxyList() []string {
+	return c.Proxy.List
+}
+func (c *Config) GetProxyFile() string {
+	return c.Proxy.File
+}
+func (c *Config) GetProxyRedisKey() string {
+	return c.Proxy.RedisKey
+}
+func (c *Config) GetProxyStrategy() pkg.ProxyStrategy {
+	switch pkg.ProxyStrategy(c.Proxy.Strategy) {
+	case pkg.ProxyStrategyRoundRobin:
+		return pkg.ProxyStrategyRoundRobin
+	case pkg.ProxyStrategyRandom:
+		return pkg.ProxyStrategyRandom
+	case pkg.ProxyStrategySticky:
+		return pkg.ProxyStrategySticky
+	default:
+		return defaultProxyStrategy
+	}
+}
+func (c *Config) GetEnableHttpCacheMiddleware() bool {
+	if c.EnableHttpCacheMiddleware == nil {
+		enableHttpCacheMiddleware := defaultEnableHttpCacheMiddleware
+		c.EnableHttpCacheMiddleware = &enableHttpCacheMiddleware
+	}
+
+	return *c.EnableHttpCacheMiddleware
+}
+func (c *Config) GetEnableSessionMiddleware() bool {
+	if c.EnableSessionMiddleware == nil {
+		enableSessionMiddleware := defaultEnableSessionMiddleware
+		c.EnableSessionMiddleware = &enableSessionMiddleware
+	}
+
+	return *c.EnableSessionMiddleware
+}
+func (c *Config) GetEnableAutoThrottleMiddleware() bool {
+	if c.EnableAutoThrottleMiddleware == nil {
+		enableAutoThrottleMiddleware := defaultEnableAutoThrottleMiddleware
+		c.EnableAutoThrottleMiddleware = &enableAutoThrottleMiddleware
+	}
+
+	return *c.EnableAutoThrottleMiddleware
+}
+func (c *Config) GetAutoThrottleTargetConcurrency() float64 {
+	if c.AutoThrottle.TargetConcurrency == nil || *c.AutoThrottle.TargetConcurrency <= 0 {
+		targetConcurrency := defaultAutoThrottleTargetConcurrency
+		c.AutoThrottle.TargetConcurrency = &targetConcurrency
+	}
+
+	return *c.AutoThrottle.TargetConcurrency
+}
+func (c *Config) GetAutoThrottleMinDelay() time.Duration {
+	return time.Duration(c.AutoThrottle.MinDelay) * time.Millisecond
+}
+func (c *Config) GetAutoThrottleMaxDelay() time.Duration {
+	if c.AutoThrottle.MaxDelay == nil {
+		maxDelay := defaultAutoThrottleMaxDelay
+		c.AutoThrottle.MaxDelay = &maxDelay
+	}
+
+	return time.Duration(*c.AutoThrottle.MaxDelay) * time.Millisecond
+}
+func (c *Config) GetHttpCacheStorage() pkg.HttpCacheStorage {
+	switch pkg.HttpCacheStorage(c.HttpCache.Storage) {
+	case pkg.HttpCacheStorageFile:
+		return pkg.HttpCacheStorageFile
+	case pkg.HttpCacheStorageSqlite:
+		return pkg.HttpCacheStorageSqlite
+	default:
+		return defaultHttpCacheStorage
+	}
+}
+func (c *Config) GetHttpCacheDir() string {
+	if c.HttpCache.Dir == "" {
+		return defaultHttpCacheDir
+	}
+
+	return c.HttpCache.Dir
+}
+func (c *Config) GetHttpCacheTtl() time.Duration {
+	return time.Duration(c.HttpCache.Ttl) * time.Second
+}
+
+// GetHttpCacheIgnoreStatusCodes the failures aren't cached by default, or they'd be replayed to the retries.
+// It's empty if it's set to [].
+func (c *Config) GetHttpCacheIgnoreStatusCodes() []int {
+	if c.HttpCache.IgnoreStatusCodes == nil {
+		return defaultHttpCacheIgnoreStatusCodes
+	}
+
+	return c.HttpCache.IgnoreStatusCodes
+}
+func (c *Config) GetHttpCachePolicy() pkg.HttpCachePolicy {
+	switch pkg.HttpCachePolicy(c.HttpCache.Policy) {
+	case pkg.HttpCachePolicyDummy:
+		return pkg.HttpCachePolicyDummy
+	case pkg.HttpCachePolicyRfc9111:
+		return pkg.HttpCachePolicyRfc9111
+	default:
+		return defaultHttpCachePolicy
+	}
+}
+func (c *Config) GetCookieStorage() pkg.CookieStorage {
+	switch pkg.CookieStorage(c.Cookie.Storage) {
+	case pkg.CookieStorageFile:
+		return pkg.CookieStorageFile
+	case pkg.CookieStorageRedis:
+		return pkg.CookieStorageRedis
+	default:
+		return pkg.CookieStorageNone
+	}
+}
+func (c *Config) GetCookieDir() string {
+	if c.Cookie.Dir == "" {
+		return defaultCookieDir
+	}
+
+	return c.Cookie.Dir
+}
+func (c *Config) GetCompressMaxRatio() uint {
+	if c.Compress.MaxRatio == nil {
+		compressMaxRatio := defaultCompressMaxRatio
+		c.Compress.MaxRatio = &compressMaxRatio
+	}
+
+	return *c.Compress.MaxRatio
+}
+func (c *Config) GetBrowserPoolSize() uint8 {
+	if c.Browser.PoolSize == nil || *c.Browser.PoolSize == 0 {
+		poolSize := defaultBrowserPoolSize
+		c.Browser.PoolSize = &poolSize
+	}
+
+	return *c.Browser.PoolSize
+}
+func (c *Config) GetBrowserHeadless() bool {
+	if c.Browser.Headless == nil {
+		headless := defaultBrowserHeadless
+		c.Browser.Headless = &headless
+	}
+
+	return *c.Browser.Headless
+}
+func (c *Config) GetBrowserFlags() []string {
+	return c.Browser.Flags
+}
+func (c *Config) GetBrowserBlock() []string {
+	if c.Browser.Block == nil {
+		c.Browser.Block = defaultBrowserBlock
+	}
+
+	return c.Browser.Block
+}
+func (c *Config) GetBrowserIdleTimeout() time.Duration {
+	if c.Browser.IdleTimeout == nil {
+		idleTimeout := defaultBrowserIdleTimeout
+		c.Browser.IdleTimeout = &idleTimeout
+	}
+
+	return time.Duration(*c.Browser.IdleTimeout) * time.Second
+}
+func (c *Config) GetBrowserRemote() string {
+	return c.Browser.Remote
+}
+func (c *Config) GetRedisQueueReliable() bool {
+	return c.RedisQueue.Reliable
+}
+func (c *Config) GetRedisQueueLease() time.Duration {
+	if c.RedisQueue.Lease == nil || *c.RedisQueue.Lease == 0 {
+		lease := defaultRedisQueueLease
+		c.RedisQueue.Lease = &lease
+	}
+
+	return time.Duration(*c.RedisQueue.Lease) * time.Second
+}
+func (c *Config) GetRedisQueueReclaimInterval() time.Duration {
+	if c.RedisQueue.ReclaimInterval == nil || *c.RedisQueue.ReclaimInterval == 0 {
+		reclaimInterval := defaultRedisQueueReclaimInterval
+		c.RedisQueue.ReclaimInterval = &reclaimInterval
+	}
+
+	return time.Duration(*c.RedisQueue.ReclaimInterval) * time.Second
+}
+func (c *Config) GetRedisQueueMaxReclaims() uint {
+	if c.RedisQueue.MaxReclaims == nil {
+		maxReclaims := defaultRedisQueueMaxReclaims
+		c.RedisQueue.MaxReclaims = &maxReclaims
+	}
+
+	return *c.RedisQueue.MaxReclaims
+}
+func (c *Config) GetKafkaQueueGroupId() string {
+	if c.KafkaQueue.GroupId == "" {
+		return c.GetBotName()
+	}
+
+	return c.KafkaQueue.GroupId
+}
+func (c *Config) GetKafkaQueueStartOffset() pkg.KafkaStartOffset {
+	switch pkg.KafkaStartOffset(c.KafkaQueue.StartOffset) {
+	case pkg.KafkaStartOffsetFirst:
+		return pkg.KafkaStartOffsetFirst
+	case pkg.KafkaStartOffsetLast:
+		return pkg.KafkaStartOffsetLast
+	default:
+		return defaultKafkaQueueStartOffset
+	}
+}
+func (c *Config) GetKafkaQueueCommitInterval() time.Duration {
+	if c.KafkaQueue.CommitInterval == nil || *c.KafkaQueue.CommitInterval == 0 {
+		commitInterval := defaultKafkaQueueCommitInterval
+		c.KafkaQueue.CommitInterval = &commitInterval
+	}
+
+	return time.Duration(*c.KafkaQueue.CommitInterval) * time.Millisecond
+}
+func (c *Config) GetKafkaQueueCommitBatch() int {
+	if c.KafkaQueue.CommitBatch == nil || *c.KafkaQueue.CommitBatch == 0 {
+		commitBatch := defaultKafkaQueueCommitBatch
+		c.KafkaQueue.CommitBatch = &commitBatch
+	}
+
+	return int(*c.KafkaQueue.CommitBatch)
+}
+func (c *Config) GetKafkaQueueDeadLetter() bool {
+	if c.KafkaQueue.DeadLetter == nil {
+		deadLetter := defaultKafkaQueueDeadLetter
+		c.KafkaQueue.DeadLetter = &deadLetter
+	}
+
+	return *c.KafkaQueue.DeadLetter
+}
+func (c *Config) GetClusterEnable() bool {
+	return c.Cluster.Enable
+}
+func (c *Config) GetClusterHeartbeat() time.Duration {
+	if c.Cluster.Heartbeat == nil || *c.Cluster.Heartbeat == 0 {
+		heartbeat := defaultClusterHeartbeat
+		c.Cluster.Heartbeat = &heartbeat
+	}
+
+	return time.Duration(*c.Cluster.Heartbeat) * time.Second
+}
+func (c *Config) GetPipelineBatchSize() int {
+	if c.PipelineBatch.Size == nil || *c.PipelineBatch.Size == 0 {
+		size := defaultPipelineBatchSize
+		c.PipelineBatch.Size = &size
+	}
+
+	return int(*c.PipelineBatch.Size)
+}
+func (c *Config) GetPipelineBatchInterval() time.Duration {
+	if c.PipelineBatch.Interval == nil || *c.PipelineBatch.Interval == 0 {
+		interval := defaultPipelineBatchInterval
+		c.PipelineBatch.Interval = &interval
+	}
+
+	return time.Duration(*c.PipelineBatch.Interval) * time.Millisecond
+}
+func (c *Config) GetSqlitePipelineKey() string {
+	if c.SqlitePipeline.Key == "" {
+		return defaultSqlitePipelineKey
+	}
+
+	return c.SqlitePipeline.Key
+}
+func (c *Config) GetSqlitePipelineCreateTable() bool {
+	return c.SqlitePipeline.CreateTable
+}
+func (c *Config) GetProxyBanStatusCodes() []int {
+	return c.Proxy.BanStatusCodes
+}
+func (c *Config) GetProxyBanTime() time.Duration {
+	if c.Proxy.BanTime == nil {
+		proxyBanTime := defaultProxyBanTime
+		c.Proxy.BanTime = &proxyBanTime
+	}
+
+	return time.Second * time.Duration(int(*c.Proxy.BanTime))
+}
+func (c *Config) GetProxyMaxFailures() uint8 {
+	if c.Proxy.MaxFailures == nil || *c.Proxy.MaxFailures == 0 {
+		proxyMaxFailures := defaultProxyMaxFailures
+		c.Proxy.MaxFailures = &proxyMaxFailures
+	}
+
+	return *c.Proxy.MaxFailures
+}
+
 func (c *Config) GetHttpProto() string {
 	if c.Request.HttpProto != "" {
 		return c.Request.HttpProto
@@ -328,6 +763,16 @@ func (c *Config) GetReferrerPolicy() pkg.ReferrerPolicy {
 	return pkg.DefaultReferrerPolicy
 }
 
+// GetRobotsTxtUserAgent the user agent used to find the group in robots.txt, bot_name by default.
+func (c *Config) GetRobotsTxtUserAgent() string {
+	if c.RobotsTxtUserAgent == nil || *c.RobotsTxtUserAgent == "" {
+		robotsTxtUserAgent := c.GetBotName()
+		c.RobotsTxtUserAgent = &robotsTxtUserAgent
+	}
+
+	return *c.RobotsTxtUserAgent
+}
+
 func (c *Config) GetEnableCookieMiddleware() bool {
 	if c.EnableCookieMiddleware == nil {
 		enableCookieMiddleware := defaultEnableCookieMiddleware
@@ -568,6 +1013,79 @@ func (c *Config) GetRetryMaxTimes() uint8 {
 
 	return *c.Request.RetryMaxTimes
 }
+func (c *Config) GetRequestSlot() pkg.SlotType {
+	switch pkg.SlotType(c.Request.Slot) {
+	case pkg.SlotTypeHost:
+		return pkg.SlotTypeHost
+	case pkg.SlotTypeDomain:
+		return pkg.SlotTypeDomain
+	default:
+		return pkg.SlotTypeNone
+	}
+}
+func (c *Config) GetRequestSlots() map[string]pkg.SlotOptions {
+	slots := make(map[string]pkg.SlotOptions, len(c.Request.Slots))
+	for k, v := range c.Request.Slots {
+		if v == nil {
+			continue
+		}
+		slots[k] = pkg.SlotOptions{
+			Interval:    time.Duration(v.Interval) * time.Millisecond,
+			Concurrency: v.Concurrency,
+		}
+	}
+	return slots
+}
+func (c *Config) GetRequestMaxBodySize() int64 {
+	return c.Request.MaxBodySize
+}
+func (c *Config) GetRetryBackoff() pkg.RetryBackoff {
+	switch pkg.RetryBackoff(c.Request.RetryBackoff) {
+	case pkg.RetryBackoffFixed:
+		return pkg.RetryBackoffFixed
+	case pkg.RetryBackoffExponential:
+		return pkg.RetryBackoffExponential
+	case pkg.RetryBackoffJitter:
+		return pkg.RetryBackoffJitter
+	default:
+		return defaultRetryBackoff
+	}
+}
+func (c *Config) GetRetryDelay() time.Duration {
+	if c.Request.RetryDelay == nil {
+		retryDelay := defaultRetryDelay
+		c.Request.RetryDelay = &retryDelay
+	}
+
+	return time.Millisecond * time.Duration(*c.Request.RetryDelay)
+}
+func (c *Config) GetRetryMaxDelay() time.Duration {
+	if c.Request.RetryMaxDelay == nil {
+		retryMaxDelay := defaultRetryMaxDelay
+		c.Request.RetryMaxDelay = &retryMaxDelay
+	}
+
+	return time.Millisecond * time.Duration(*c.Request.RetryMaxDelay)
+}
+func (c *Config) GetRetryAfter() bool {
+	if c.Request.RetryAfter == nil {
+		retryAfter := defaultRetryAfter
+		c.Request.RetryAfter = &retryAfter
+	}
+
+	return *c.Request.RetryAfter
+}
+func (c *Config) GetRetryErrors() []pkg.RetryErrorClass {
+	if c.Request.RetryErrors == nil {
+		c.Request.RetryErrors = defaultRetryErrors
+	}
+
+	retryErrors := make([]pkg.RetryErrorClass, len(c.Request.RetryErrors))
+	for i, v := range c.Request.RetryErrors {
+		retryErrors[i] = pkg.RetryErrorClass(v)
+	}
+	return retryErrors
+}
 
 func (c *Config) GetEnableChromeMiddleware() bool {
 	if c.EnableChromeMiddleware == nil {
@@ -642,6 +1160,42 @@ func (c *Config) GetRequestTimeout() time.Duration {
 	return time.Second * time.Duration(int(*c.Request.Timeout))
 }
 
+func (c *Config) GetRequestMaxIdleConns() int {
+	if c.Request.MaxIdleConns == nil {
+		requestMaxIdleConns := defaultRequestMaxIdleConns
+		c.Request.MaxIdleConns = &requestMaxIdleConns
+	}
+
+	return *c.Request.MaxIdleConns
+}
+
+func (c *Config) GetRequestMaxIdleConnsPerHost() int {
+	if c.Request.MaxIdleConnsPerHost == nil {
+		requestMaxIdleConnsPerHost := defaultRequestMaxIdleConnsPerHost
+		c.Request.MaxIdleConnsPerHost = &requestMaxIdleConnsPerHost
+	}
+
+	return *c.Request.MaxIdleConnsPerHost
+}
+
+func (c *Config) GetRequestMaxConnsPerHost() int {
+	if c.Request.MaxConnsPerHost == nil {
+		requestMaxConnsPerHost := defaultRequestMaxConnsPerHost
+		c.Request.MaxConnsPerHost = &requestMaxConnsPerHost
+	}
+
+	return *c.Request.MaxConnsPerHost
+}
+
+func (c *Config) GetRequestIdleConnTimeout() time.Duration {
+	if c.Request.IdleConnTimeout == nil {
+		requestIdleConnTimeout := defaultRequestIdleConnTimeout
+		c.Request.IdleConnTimeout = &requestIdleConnTimeout
+	}
+
+	return time.Second * time.Duration(int(*c.Request.IdleConnTimeout))
+}
+
 func (c *Config) GetOkHttpCodes() []int {
 	if len(c.Request.OkHttpCodes) == 0 {
 		c.Request.OkHttpCodes = []int{200}
@@ -680,6 +1234,10 @@ func (c *Config) GetFilter() pkg.FilterType {
 			return pkg.FilterMemory
 		case pkg.FilterRedis:
 			return pkg.FilterRedis
+		case pkg.FilterBloom:
+			return pkg.FilterBloom
+		case pkg.FilterSqlite:
+			return pkg.FilterSqlite
 		default:
 			return pkg.FilterUnknown
 		}
@@ -687,6 +1245,52 @@ func (c *Config) GetFilter() pkg.FilterType {
 
 	return pkg.FilterUnknown
 }
+
+// GetFilterBloomCapacity is the initial capacity of the bloom filter, it grows when it's full.
+func (c *Config) GetFilterBloomCapacity() uint {
+	if c.FilterBloom.Capacity == nil || *c.FilterBloom.Capacity == 0 {
+		capacity := defaultFilterBloomCapacity
+		c.FilterBloom.Capacity = &capacity
+	}
+
+	return *c.FilterBloom.Capacity
+}
+func (c *Config) GetFilterBloomFalsePositiveRate() float64 {
+	if c.FilterBloom.FalsePositiveRate == nil || *c.FilterBloom.FalsePositiveRate <= 0 || *c.FilterBloom.FalsePositiveRate >= 1 {
+		falsePositiveRate := defaultFilterBloomFalsePositiveRate
+		c.FilterBloom.FalsePositiveRate = &falsePositiveRate
+	}
+
+	return *c.FilterBloom.FalsePositiveRate
+}
+
+// GetRequestFingerprintFilter whether the filter uses the fingerprint of the request if the unique key is not set.
+func (c *Config) GetRequestFingerprintFilter() bool {
+	if c.RequestFingerprint.Filter == nil {
+		filter := defaultRequestFingerprintFilter
+		c.RequestFingerprint.Filter = &filter
+	}
+
+	return *c.RequestFingerprint.Filter
+}
+func (c *Config) GetRequestFingerprintIgnoreParams() []string {
+	if c.RequestFingerprint.IgnoreParams == nil {
+		c.RequestFingerprint.IgnoreParams = defaultRequestFingerprintIgnoreParams
+	}
+
+	return c.RequestFingerprint.IgnoreParams
+}
+func (c *Config) GetRequestFingerprintHeaders() []string {
+	return c.RequestFingerprint.Headers
+}
+func (c *Config) GetRequestFingerprintBody() bool {
+	if c.RequestFingerprint.Body == nil {
+		body := defaultRequestFingerprintBody
+		c.RequestFingerprint.Body = &body
+	}
+
+	return *c.RequestFingerprint.Body
+}
 func (c *Config) GetSqlite() []*Sqlite {
 	return c.Sqlite
 }
@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"io"
+	"net/http"
+)
+
+// CookieJarDefault is the name of the cookie jar of the requests without cookie jar.
+const CookieJarDefault = "default"
+
+// CookieJar is an RFC 6265 cookie jar.
+type CookieJar interface {
+	http.CookieJar
+	// All returns the unexpired cookies with the domain, path and expiry.
+	// The domain has a leading dot if the cookie is sent to the subdomains, otherwise it's the host.
+	All() []*http.Cookie
+	// Add adds the cookies as returned by All, e.g. the cookies of a browser.
+	Add(...*http.Cookie)
+	Clear()
+	// Import adds the cookies in the Netscape cookies.txt format.
+	Import(io.Reader) error
+	// Export writes the cookies in the Netscape cookies.txt format.
+	Export(io.Writer) error
+}
+
+type CookieStorage string
+
+const (
+	CookieStorageNone  CookieStorage = ""
+	CookieStorageFile  CookieStorage = "file"
+	CookieStorageRedis CookieStorage = "redis"
+)
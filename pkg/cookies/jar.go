@@ -0,0 +1,339 @@
+package cookies
+
+import (
+	"errors"
+	"golang.org/x/net/publicsuffix"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errIllegalDomain   = errors.New("illegal cookie domain attribute")
+	errMalformedDomain = errors.New("malformed cookie domain attribute")
+	errNoHostname      = errors.New("no host name available (IP only)")
+)
+
+// entry is a cookie in the jar, as the storage model of RFC 6265 section 5.3.
+type entry struct {
+	Name       string
+	Value      string
+	Domain     string
+	Path       string
+	SameSite   http.SameSite
+	Secure     bool
+	HttpOnly   bool
+	Persistent bool
+	HostOnly   bool
+	Expires    time.Time
+	Creation   time.Time
+	seqNum     uint64 // orders the cookies created at the same time
+}
+
+func (e *entry) id() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return e.Persistent && !e.Expires.After(now)
+}
+
+func (e *entry) shouldSend(https bool, host, path string) bool {
+	return e.domainMatch(host) && pathMatch(path, e.Path) && (https || !e.Secure)
+}
+
+func (e *entry) domainMatch(host string) bool {
+	if e.Domain == host {
+		return true
+	}
+	return !e.HostOnly && hasDotSuffix(host, e.Domain)
+}
+
+func (e *entry) cookie() *http.Cookie {
+	c := &http.Cookie{
+		Name:     e.Name,
+		Value:    e.Value,
+		Domain:   e.Domain,
+		Path:     e.Path,
+		Secure:   e.Secure,
+		HttpOnly: e.HttpOnly,
+		SameSite: e.SameSite,
+	}
+	if !e.HostOnly {
+		c.Domain = "." + e.Domain
+	}
+	if e.Persistent {
+		c.Expires = e.Expires
+	}
+	return c
+}
+
+// Jar is an RFC 6265 cookie jar, the cookies are scoped by the domain, path, expiry and Secure attribute.
+type Jar struct {
+	mutex   sync.Mutex
+	entries map[string]entry
+	nextSeq uint64
+	now     func() time.Time
+}
+
+// Cookies returns the cookies to send in a request for the url.
+func (j *Jar) Cookies(u *url.URL) (cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+	https := u.Scheme == "https"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	now := j.now()
+	var selected []entry
+	for id, e := range j.entries {
+		if e.expired(now) {
+			delete(j.entries, id)
+			continue
+		}
+		if !e.shouldSend(https, host, path) {
+			continue
+		}
+		selected = append(selected, e)
+	}
+
+	// the cookies with longer paths are listed first, then the earlier created ones
+	sort.Slice(selected, func(i, k int) bool {
+		if len(selected[i].Path) != len(selected[k].Path) {
+			return len(selected[i].Path) > len(selected[k].Path)
+		}
+		if !selected[i].Creation.Equal(selected[k].Creation) {
+			return selected[i].Creation.Before(selected[k].Creation)
+		}
+		return selected[i].seqNum < selected[k].seqNum
+	})
+	for _, e := range selected {
+		cookies = append(cookies, &http.Cookie{Name: e.Name, Value: e.Value})
+	}
+	return
+}
+
+// SetCookies stores the cookies received in a response from the url.
+// The cookies with an illegal domain are ignored, the expired ones are removed.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	host, err := canonicalHost(u.Host)
+	if err != nil {
+		return
+	}
+	defPath := defaultPath(u.Path)
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	now := j.now()
+	for _, c := range cookies {
+		domain, hostOnly, e := domainAndType(host, c.Domain)
+		if e != nil {
+			continue
+		}
+		path := c.Path
+		if path == "" || path[0] != '/' {
+			path = defPath
+		}
+		j.set(c, domain, path, hostOnly, now)
+	}
+}
+
+// All returns the unexpired cookies, the domain of the cookies sent to the subdomains has a leading dot.
+func (j *Jar) All() (cookies []*http.Cookie) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	now := j.now()
+	var all []entry
+	for id, e := range j.entries {
+		if e.expired(now) {
+			delete(j.entries, id)
+			continue
+		}
+		all = append(all, e)
+	}
+
+	sort.Slice(all, func(i, k int) bool {
+		return all[i].seqNum < all[k].seqNum
+	})
+	for _, e := range all {
+		cookies = append(cookies, e.cookie())
+	}
+	return
+}
+
+// Add adds the cookies as returned by All, the cookies without domain are ignored.
+// The domain isn't checked against the public suffix list, so only the trusted cookies should be added.
+func (j *Jar) Add(cookies ...*http.Cookie) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	now := j.now()
+	for _, c := range cookies {
+		hostOnly := !strings.HasPrefix(c.Domain, ".")
+		domain, err := canonicalHost(strings.TrimPrefix(c.Domain, "."))
+		if err != nil || domain == "" {
+			continue
+		}
+		path := c.Path
+		if path == "" || path[0] != '/' {
+			path = "/"
+		}
+		j.set(c, domain, path, hostOnly, now)
+	}
+}
+
+func (j *Jar) Clear() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.entries = make(map[string]entry)
+}
+
+// set stores the cookie, or removes it if it's expired. The mutex is held by the caller.
+func (j *Jar) set(c *http.Cookie, domain string, path string, hostOnly bool, now time.Time) {
+	e := entry{
+		Name:     c.Name,
+		Value:    c.Value,
+		Domain:   domain,
+		Path:     path,
+		SameSite: c.SameSite,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+		HostOnly: hostOnly,
+	}
+
+	// MaxAge<0 means "Max-Age: 0" in the response
+	switch {
+	case c.MaxAge < 0:
+		delete(j.entries, e.id())
+		return
+	case c.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		e.Persistent = true
+	case !c.Expires.IsZero():
+		if !c.Expires.After(now) {
+			delete(j.entries, e.id())
+			return
+		}
+		e.Expires = c.Expires
+		e.Persistent = true
+	}
+
+	id := e.id()
+	if old, ok := j.entries[id]; ok {
+		e.Creation = old.Creation
+		e.seqNum = old.seqNum
+	} else {
+		e.Creation = now
+		e.seqNum = j.nextSeq
+		j.nextSeq++
+	}
+	j.entries[id] = e
+}
+
+// canonicalHost strips the port and the trailing dot of the host, and lowercases it.
+func canonicalHost(host string) (string, error) {
+	if strings.Contains(host, ":") {
+		h, _, err := net.SplitHostPort(host)
+		if err == nil {
+			host = h
+		} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		} else if strings.HasPrefix(host, "[") {
+			return "", err
+		}
+		// otherwise it's an IPv6 address without port
+	}
+	host = strings.TrimSuffix(host, ".")
+	return strings.ToLower(host), nil
+}
+
+// defaultPath is the default path of the cookies as RFC 6265 section 5.1.4.
+func defaultPath(path string) string {
+	if len(path) == 0 || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
+
+// domainAndType returns the domain of the cookie and whether it's sent to the host only.
+func domainAndType(host string, domain string) (string, bool, error) {
+	if domain == "" {
+		return host, true, nil
+	}
+
+	if net.ParseIP(host) != nil {
+		// an IP address can only set the host-only cookies
+		if strings.TrimPrefix(domain, ".") != host {
+			return "", false, errNoHostname
+		}
+		return host, true, nil
+	}
+
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if len(domain) == 0 || domain[0] == '.' || domain[len(domain)-1] == '.' {
+		return "", false, errMalformedDomain
+	}
+
+	// the cookies for a public suffix are only allowed for the host itself, e.g. "co.uk"
+	if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+		if host == domain {
+			return host, true, nil
+		}
+		return "", false, errIllegalDomain
+	}
+
+	if !hasDotSuffix(host, domain) && host != domain {
+		return "", false, errIllegalDomain
+	}
+	return domain, false, nil
+}
+
+func hasDotSuffix(s, suffix string) bool {
+	return len(s) > len(suffix) && s[len(s)-len(suffix)-1] == '.' && s[len(s)-len(suffix):] == suffix
+}
+
+// pathMatch implements the path-match of RFC 6265 section 5.1.4.
+func pathMatch(requestPath string, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	if strings.HasPrefix(requestPath, cookiePath) {
+		return cookiePath[len(cookiePath)-1] == '/' || requestPath[len(cookiePath)] == '/'
+	}
+	return false
+}
+
+func NewJar() (jar *Jar) {
+	jar = &Jar{
+		entries: make(map[string]entry),
+		now:     time.Now,
+	}
+	return
+}
@@ -0,0 +1,107 @@
+package cookies
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cookieNames(cookies []*http.Cookie) string {
+	var names []string
+	for _, c := range cookies {
+		names = append(names, c.Name+"="+c.Value)
+	}
+	return strings.Join(names, " ")
+}
+
+func TestJar(t *testing.T) {
+	jar := NewJar()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jar.now = func() time.Time { return now }
+
+	u, _ := url.Parse("https://www.example.com/a/b")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "host", Value: "1"},
+		{Name: "domain", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "path", Value: "3", Path: "/a"},
+		{Name: "secure", Value: "4", Secure: true},
+		{Name: "expires", Value: "5", MaxAge: 60},
+		{Name: "suffix", Value: "6", Domain: "com"},
+		{Name: "other", Value: "7", Domain: "example.org"},
+	})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.example.com/a/b", "host=1 path=3 secure=4 expires=5 domain=2"},
+		{"http://www.example.com/a", "host=1 path=3 expires=5 domain=2"},
+		{"https://api.example.com/a", "domain=2"},
+		{"https://www.example.com/ab", "domain=2"},
+		{"https://example.org/", ""},
+		{"ftp://www.example.com/a", ""},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := cookieNames(jar.Cookies(u)); got != tt.want {
+			t.Errorf("Cookies(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if got := cookieNames(jar.Cookies(u)); strings.Contains(got, "expires") {
+		t.Errorf("expired cookie is sent: %q", got)
+	}
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "host", MaxAge: -1}})
+	if got := cookieNames(jar.Cookies(u)); strings.Contains(got, "host") {
+		t.Errorf("deleted cookie is sent: %q", got)
+	}
+}
+
+func TestJarNetscape(t *testing.T) {
+	jar := NewJar()
+	u, _ := url.Parse("https://www.example.com/")
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "host", Value: "1", HttpOnly: true},
+		{Name: "domain", Value: "2", Domain: "example.com", Secure: true, Expires: expires},
+	})
+
+	var buf bytes.Buffer
+	if err := jar.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "# Netscape HTTP Cookie File\n\n" +
+		"#HttpOnly_www.example.com\tFALSE\t/\tFALSE\t0\thost\t1\n" +
+		".example.com\tTRUE\t/\tTRUE\t" + strconv.FormatInt(expires.Unix(), 10) + "\tdomain\t2\n"
+	if buf.String() != want {
+		t.Fatalf("Export() = %q, want %q", buf.String(), want)
+	}
+
+	imported := NewJar()
+	if err := imported.Import(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		url  string
+		want string
+	}{
+		{"https://www.example.com/", "host=1 domain=2"},
+		{"https://api.example.com/", "domain=2"},
+		{"http://www.example.com/", "host=1"},
+	} {
+		u, _ := url.Parse(tt.url)
+		if got := cookieNames(imported.Cookies(u)); got != tt.want {
+			t.Errorf("Cookies(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	if err := imported.Import(strings.NewReader("example.com\tFALSE\t/\n")); err == nil {
+		t.Error("Import() of a malformed line should fail")
+	}
+}
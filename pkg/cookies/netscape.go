@@ -0,0 +1,120 @@
+package cookies
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const netscapeHeader = "# Netscape HTTP Cookie File"
+
+// httpOnlyPrefix marks the http-only cookies in the domain field, as curl does.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// Import adds the cookies in the Netscape cookies.txt format, the expired cookies are ignored.
+// Every line has 7 tab-separated fields: domain, include subdomains, path, secure, expiry (unix seconds, 0 for session), name and value.
+func (j *Jar) Import(r io.Reader) (err error) {
+	var cookies []*http.Cookie
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(text, httpOnlyPrefix) {
+			httpOnly = true
+			text = text[len(httpOnlyPrefix):]
+		}
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) == 6 {
+			// a cookie without value
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			err = fmt.Errorf("cookies.txt line %d: %d fields", line, len(fields))
+			return
+		}
+
+		var expires int64
+		expires, err = strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			err = fmt.Errorf("cookies.txt line %d: %w", line, err)
+			return
+		}
+
+		domain := strings.TrimPrefix(fields[0], ".")
+		if strings.EqualFold(fields[1], "TRUE") {
+			domain = "." + domain
+		}
+		c := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   domain,
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+			if !c.Expires.After(j.now()) {
+				continue
+			}
+		}
+		cookies = append(cookies, c)
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	j.Add(cookies...)
+	return
+}
+
+// Export writes the unexpired cookies in the Netscape cookies.txt format, which can be read by curl and wget.
+func (j *Jar) Export(w io.Writer) (err error) {
+	bw := bufio.NewWriter(w)
+	if _, err = bw.WriteString(netscapeHeader + "\n\n"); err != nil {
+		return
+	}
+
+	for _, c := range j.All() {
+		domain := c.Domain
+		if c.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		if _, err = fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain,
+			netscapeBool(strings.HasPrefix(c.Domain, ".")),
+			c.Path,
+			netscapeBool(c.Secure),
+			expires,
+			c.Name,
+			c.Value,
+		); err != nil {
+			return
+		}
+	}
+
+	err = bw.Flush()
+	return
+}
+
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
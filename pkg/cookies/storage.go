@@ -0,0 +1,123 @@
+package cookies
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/redis/go-redis/v9"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage persists the cookie jars of a spider by the jar name, in the Netscape cookies.txt format.
+type Storage interface {
+	Load() (map[string]pkg.CookieJar, error)
+	Save(name string, jar pkg.CookieJar) error
+}
+
+// FileStorage stores every jar as a <name>.txt file in the dir, the name is escaped.
+type FileStorage struct {
+	dir string
+}
+
+func (s *FileStorage) Load() (jars map[string]pkg.CookieJar, err error) {
+	jars = make(map[string]pkg.CookieJar)
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.txt"))
+	if err != nil {
+		return
+	}
+
+	for _, path := range paths {
+		var f *os.File
+		f, err = os.Open(path)
+		if err != nil {
+			return
+		}
+		jar := NewJar()
+		err = jar.Import(f)
+		_ = f.Close()
+		if err != nil {
+			return
+		}
+		name, e := url.PathUnescape(strings.TrimSuffix(filepath.Base(path), ".txt"))
+		if e != nil {
+			continue
+		}
+		jars[name] = jar
+	}
+	return
+}
+
+func (s *FileStorage) Save(name string, jar pkg.CookieJar) (err error) {
+	var buf bytes.Buffer
+	if err = jar.Export(&buf); err != nil {
+		return
+	}
+
+	// write to a temporary file first, so a reader won't get a partial jar
+	path := filepath.Join(s.dir, url.PathEscape(name)+".txt")
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return
+	}
+	err = os.Rename(tmp, path)
+	return
+}
+
+func NewFileStorage(dir string) (storage *FileStorage, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	storage = &FileStorage{
+		dir: dir,
+	}
+	return
+}
+
+// RedisStorage stores the jars in a hash, the field is the jar name.
+type RedisStorage struct {
+	rdb *redis.Client
+	key string
+}
+
+func (s *RedisStorage) Load() (jars map[string]pkg.CookieJar, err error) {
+	jars = make(map[string]pkg.CookieJar)
+	values, err := s.rdb.HGetAll(context.Background(), s.key).Result()
+	if err != nil {
+		return
+	}
+
+	for name, value := range values {
+		jar := NewJar()
+		if err = jar.Import(strings.NewReader(value)); err != nil {
+			return
+		}
+		jars[name] = jar
+	}
+	return
+}
+
+func (s *RedisStorage) Save(name string, jar pkg.CookieJar) (err error) {
+	var buf bytes.Buffer
+	if err = jar.Export(&buf); err != nil {
+		return
+	}
+
+	err = s.rdb.HSet(context.Background(), s.key, name, buf.String()).Err()
+	return
+}
+
+func NewRedisStorage(rdb *redis.Client, key string) (storage *RedisStorage, err error) {
+	if rdb == nil {
+		err = errors.New("redis nil")
+		return
+	}
+	storage = &RedisStorage{
+		rdb: rdb,
+		key: key,
+	}
+	return
+}
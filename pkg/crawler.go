@@ -42,6 +42,9 @@ type Crawler interface {
 	GetStatistics() Statistics
 	SetStatistics(statistics Statistics)
 
+	// GetCluster returns nil if the cluster isn't enabled.
+	GetCluster() Cluster
+
 	GetItemDelay() time.Duration
 	WithItemDelay(time.Duration) Crawler
 	GetItemConcurrency() uint8
@@ -53,6 +56,7 @@ type Crawler interface {
 	GenUid() uint64
 
 	StartFromCLI() bool
+	GetCheckpoint() string
 
 	GetStream() Stream
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/api"
 	"github.com/lizongying/go-crawler/pkg/cli"
+	"github.com/lizongying/go-crawler/pkg/cluster"
 	"github.com/lizongying/go-crawler/pkg/config"
 	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
 	"github.com/lizongying/go-crawler/pkg/loggers"
@@ -29,6 +30,7 @@ type Crawler struct {
 	args        string
 	mode        pkg.JobMode
 	spec        string
+	checkpoint  string
 	config      pkg.Config
 	logger      pkg.Logger
 	MongoDb     *mongo.Database
@@ -41,6 +43,7 @@ type Crawler struct {
 	mockServer  pkg.MockServer
 	api         *api.Api
 	statistics  pkg.Statistics
+	cluster     pkg.Cluster
 	pkg.Signal
 
 	spider *pkg.State
@@ -78,6 +81,9 @@ func (c *Crawler) GetStatistics() pkg.Statistics {
 func (c *Crawler) SetStatistics(statistics pkg.Statistics) {
 	c.statistics = statistics
 }
+func (c *Crawler) GetCluster() pkg.Cluster {
+	return c.cluster
+}
 func (c *Crawler) GetLogger() pkg.Logger {
 	return c.logger
 }
@@ -161,6 +167,9 @@ func (c *Crawler) ItemConcurrencyChan() chan struct{} {
 func (c *Crawler) StartFromCLI() bool {
 	return c.spiderName != ""
 }
+func (c *Crawler) GetCheckpoint() string {
+	return c.checkpoint
+}
 func (c *Crawler) RunJob(ctx context.Context, spiderName string, startFunc string, args string, mode pkg.JobMode, spec string) (id string, err error) {
 	var spider pkg.Spider
 	for _, v := range c.spiders {
@@ -275,6 +284,21 @@ func (c *Crawler) Start(ctx context.Context) (err error) {
 		c.itemConcurrencyChan <- struct{}{}
 	}
 
+	if c.config.GetClusterEnable() {
+		c.cluster = new(cluster.Cluster).FromCrawler(c)
+		if err = c.cluster.Start(ctx); err != nil {
+			c.logger.Error(err)
+			return
+		}
+		defer func() {
+			ctxStop, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if e := c.cluster.Stop(ctxStop); e != nil {
+				c.logger.Error(e)
+			}
+		}()
+	}
+
 	if err = c.api.Run(); err != nil {
 		c.logger.Error(err)
 		return
@@ -346,6 +370,7 @@ func NewCrawler(spiders []pkg.Spider, cli *cli.Cli, config *config.Config, logge
 		args:        cli.Args,
 		mode:        pkg.JobModeFromString(cli.Mode),
 		spec:        cli.Spec,
+		checkpoint:  cli.Checkpoint,
 		config:      config,
 		logger:      logger,
 		MongoDb:     mongoDb,
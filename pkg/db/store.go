@@ -3,19 +3,27 @@ package db
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/config"
 	"github.com/lizongying/go-crawler/pkg/utils"
 	"go.uber.org/fx"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// multipartPartSize is the minimum part size of the s3 multipart upload, except the last part.
+const multipartPartSize = 5 << 20
+
+const partialExt = ".part"
+
 type Store struct {
 	Config *config.Store
 	*s3.Client
@@ -99,6 +107,169 @@ func (s *Store) Save(prefix string, key string, body []byte) (storePath string,
 	return
 }
 
+// SaveReader
+// is s3:
+//
+//	prefix=bucket, the body is uploaded by parts
+//
+// is file:
+//
+//	prefix=dir, the body is written to a partial file, then it's moved to the key
+func (s *Store) SaveReader(prefix string, key string, body io.Reader) (storePath string, err error) {
+	if s.Client != nil {
+		if prefix == "" {
+			prefix = s.Config.Bucket
+		}
+		prefix = strings.Trim(prefix, "/")
+
+		if err = s.uploadParts(prefix, key, body); err != nil {
+			s.logger.Error(err)
+			return
+		}
+		storePath = fmt.Sprintf("%s://%s/%s", s.Config.Type, prefix, key)
+		return
+	}
+
+	file, err := s.OpenPartial(prefix, key)
+	if err != nil {
+		return
+	}
+	if err = file.Truncate(0); err == nil {
+		_, err = io.Copy(file, body)
+	}
+	err = errors.Join(err, file.Close())
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+
+	return s.CommitPartial(prefix, key, key)
+}
+
+func (s *Store) uploadParts(bucket string, key string, body io.Reader) (err error) {
+	ctx := context.Background()
+	upload, err := s.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: &bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_, e := s.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   &bucket,
+				Key:      &key,
+				UploadId: upload.UploadId,
+			})
+			err = errors.Join(err, e)
+		}
+	}()
+
+	var parts []types.CompletedPart
+	buf := make([]byte, multipartPartSize)
+	for partNumber := int32(1); ; partNumber++ {
+		n, e := io.ReadFull(body, buf)
+		if e != nil && !errors.Is(e, io.EOF) && !errors.Is(e, io.ErrUnexpectedEOF) {
+			err = e
+			return
+		}
+		// an empty body is still uploaded as one part
+		if n > 0 || partNumber == 1 {
+			var part *s3.UploadPartOutput
+			part, err = s.UploadPart(ctx, &s3.UploadPartInput{
+				Bucket:     &bucket,
+				Key:        &key,
+				UploadId:   upload.UploadId,
+				PartNumber: aws.Int32(partNumber),
+				Body:       bytes.NewReader(buf[:n]),
+			})
+			if err != nil {
+				return
+			}
+			parts = append(parts, types.CompletedPart{
+				ETag:       part.ETag,
+				PartNumber: aws.Int32(partNumber),
+			})
+		}
+		if e != nil {
+			break
+		}
+	}
+
+	_, err = s.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &bucket,
+		Key:             &key,
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	return
+}
+
+// localDir returns the dir of the local store.
+// It's stable if the endpoint isn't set, so the partial files can be found by the next run.
+func (s *Store) localDir(prefix string) string {
+	if s.Config != nil && s.Config.Endpoint != "" {
+		prefix = strings.TrimPrefix(s.Config.Endpoint, "file:/")
+	}
+	if prefix == "" {
+		bucket := ""
+		if s.Config != nil {
+			bucket = s.Config.Bucket
+		}
+		prefix = filepath.Join(os.TempDir(), bucket)
+	}
+	return fmt.Sprintf("/%s", strings.Trim(prefix, "/"))
+}
+
+func (s *Store) PartialSize(prefix string, name string) (size int64, ok bool) {
+	if s.Client != nil {
+		return
+	}
+
+	ok = true
+	info, err := os.Stat(filepath.Join(s.localDir(prefix), name+partialExt))
+	if err != nil {
+		return
+	}
+	size = info.Size()
+	return
+}
+
+func (s *Store) OpenPartial(prefix string, name string) (file *os.File, err error) {
+	if s.Client != nil {
+		err = errors.New("partial files aren't supported by s3")
+		return
+	}
+
+	filename := filepath.Join(s.localDir(prefix), name+partialExt)
+	if err = os.MkdirAll(filepath.Dir(filename), 0744); err != nil {
+		s.logger.Error(err)
+		return
+	}
+	file, err = os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		s.logger.Error(err)
+		return
+	}
+	return
+}
+
+func (s *Store) CommitPartial(prefix string, name string, key string) (storePath string, err error) {
+	dir := s.localDir(prefix)
+	filename := filepath.Join(dir, key)
+	if err = os.MkdirAll(filepath.Dir(filename), 0744); err != nil {
+		s.logger.Error(err)
+		return
+	}
+	if err = os.Rename(filepath.Join(dir, name+partialExt), filename); err != nil {
+		s.logger.Error(err)
+		return
+	}
+	storePath = fmt.Sprintf("file:/%s", filename)
+	return
+}
+
 func NewStore(config *config.Config, logger pkg.Logger, lc fx.Lifecycle) (store *Store, err error) {
 	store = new(Store)
 	store.logger = logger
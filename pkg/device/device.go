@@ -93,3 +93,23 @@ func readCsvFileFromBytes(bs []byte) (devices map[string][]Device) {
 
 	return
 }
+
+// PlatformOf returns the platform of the user agent, e.g. iphone for "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X)".
+func PlatformOf(userAgent string) pkg.Platform {
+	switch {
+	case strings.Contains(userAgent, "iPad"):
+		return pkg.PlatformIpad
+	case strings.Contains(userAgent, "iPhone"):
+		return pkg.PlatformIphone
+	case strings.Contains(userAgent, "Android"):
+		return pkg.PlatformAndroid
+	case strings.Contains(userAgent, "Windows"):
+		return pkg.PlatformWindows
+	case strings.Contains(userAgent, "Macintosh"), strings.Contains(userAgent, "Mac OS X"):
+		return pkg.PlatformMac
+	case strings.Contains(userAgent, "Linux"), strings.Contains(userAgent, "X11"):
+		return pkg.PlatformLinux
+	default:
+		return pkg.PlatformUnknown
+	}
+}
@@ -9,3 +9,17 @@ func TestNewDevices(t *testing.T) {
 	devices, _ := NewDevicesFromBytes(static.Devices)
 	t.Log(devices.Devices)
 }
+
+func TestPlatformOf(t *testing.T) {
+	devices, _ := NewDevicesFromBytes(static.Devices)
+	for key, list := range devices.Devices {
+		for _, d := range list {
+			if d.Platform == "" || d.UserAgent == "" {
+				continue
+			}
+			if platform := PlatformOf(d.UserAgent); platform != d.Platform {
+				t.Errorf("%s: PlatformOf(%q) = %q", key, d.UserAgent, platform)
+			}
+		}
+	}
+}
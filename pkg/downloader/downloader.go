@@ -42,30 +42,55 @@ func (d *Downloader) Download(ctx pkg.Context, request pkg.Request) (response pk
 		return
 	}
 
-	client := d.httpClient
-	if request.GetClient() == pkg.ClientBrowser {
-		var b *browser.Browser
-		b, err = d.browserManager.Pop(context.Background())
+	response, err = d.responseFromMiddlewares(ctx, request)
+	if err != nil {
+		d.logger.Error(err)
+		return
+	}
+
+	if response == nil {
+		client := d.httpClient
+		if request.GetClient() == pkg.ClientBrowser {
+			var b *browser.Browser
+			b, err = d.browserManager.Pop(context.Background())
+			if err != nil {
+				d.logger.Error(err)
+				return
+			}
+			client = b
+			defer d.browserManager.Put(b)
+		}
+		response, err = client.DoRequest(request.RequestContext(), request)
 		if err != nil {
 			d.logger.Error(err)
-			return
+			if errors.Is(err, pkg.ErrBodyTooLarge) {
+				return
+			}
 		}
-		client = b
-		defer d.browserManager.Put(b)
 	}
-	response, err = client.DoRequest(request.RequestContext(), request)
-	if err != nil {
-		d.logger.Error(err)
+
+	// the body of the files is streamed by the middlewares, it's closed if they didn't
+	if request.IsFile() && response != nil && response.GetResponse() != nil {
+		defer func() {
+			if e := response.GetBody().Close(); e != nil {
+				d.logger.Debug(e)
+			}
+		}()
 	}
 
 	if response == nil {
 		response = new(response2.Response)
 		response.SetRequest(request)
 	}
+	if err != nil {
+		response.SetError(err)
+	}
 
 	if err = d.processResponse(ctx, response); err != nil {
+		// the retry is left to the scheduler, so the goroutine isn't blocked by the backoff
 		if errors.Is(err, pkg.ErrNeedRetry) {
-			return d.Download(ctx, request)
+			d.logger.Debug(err)
+			return
 		}
 		d.logger.Error(err)
 		return
@@ -99,6 +124,25 @@ func (d *Downloader) processRequest(ctx pkg.Context, request pkg.Request) (err e
 	}
 	return
 }
+
+// responseFromMiddlewares returns the first response of the middlewares able to respond without downloading.
+func (d *Downloader) responseFromMiddlewares(ctx pkg.Context, request pkg.Request) (response pkg.Response, err error) {
+	if request.IsSkipMiddleware() {
+		return
+	}
+	for _, v := range d.middlewares.Middlewares() {
+		m, ok := v.(pkg.MiddlewareWithResponse)
+		if !ok {
+			continue
+		}
+		response, err = m.Response(ctx, request)
+		if err != nil || response != nil {
+			return
+		}
+	}
+	return
+}
+
 func (d *Downloader) processResponse(ctx pkg.Context, response pkg.Response) (err error) {
 	if response.SkipMiddleware() {
 		return
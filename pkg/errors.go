@@ -2,6 +2,8 @@ package pkg
 
 import (
 	"errors"
+	"fmt"
+	"time"
 )
 
 var DontStopErr = errors.New("don't stop")
@@ -12,6 +14,48 @@ var ErrIgnoreResponse = errors.New("IgnoreResponse")
 var ErrNeedRetry = errors.New("need retry")
 var ErrUrlLengthLimit = errors.New("UrlLengthLimit")
 var ErrDropItem = errors.New("DropItem")
+var ErrBodyTooLarge = errors.New("body too large")
+var ErrDecompressTooLarge = errors.New("decompressed body too large")
+var ErrSessionInvalid = errors.New("session invalid")
+var ErrNoSession = errors.New("no valid session")
 
 var ErrQueueTimeout = errors.New("queue timeout")
 var ErrTimeout = errors.New("timeout")
+
+// RetryError asks the scheduler to retry the request after the delay.
+type RetryError struct {
+	Delay time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s after %s", ErrNeedRetry, e.Delay)
+}
+func (e *RetryError) Unwrap() error {
+	return ErrNeedRetry
+}
+
+// RetryDelay reports whether the error asks for a retry, and the delay before it.
+func RetryDelay(err error) (delay time.Duration, ok bool) {
+	if !errors.Is(err, ErrNeedRetry) {
+		return
+	}
+
+	var retryError *RetryError
+	if errors.As(err, &retryError) {
+		delay = retryError.Delay
+	}
+	ok = true
+	return
+}
+
+// BodyTooLargeError is returned if the response body exceeds the max body size.
+type BodyTooLargeError struct {
+	MaxBodySize int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("%s: exceeds %d bytes", ErrBodyTooLarge, e.MaxBodySize)
+}
+func (e *BodyTooLargeError) Unwrap() error {
+	return ErrBodyTooLarge
+}
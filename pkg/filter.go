@@ -1,11 +1,15 @@
 package pkg
 
+import "io"
+
 type FilterType string
 
 const (
 	FilterUnknown FilterType = ""
 	FilterMemory  FilterType = "memory"
 	FilterRedis   FilterType = "redis"
+	FilterBloom   FilterType = "bloom"
+	FilterSqlite  FilterType = "sqlite"
 )
 
 type Filter interface {
@@ -13,3 +17,10 @@ type Filter interface {
 	Store(Context, any) error
 	Clean(Context) error
 }
+
+// FilterWithCheckpoint is implemented by the filters kept in memory, so their state can be saved and resumed.
+type FilterWithCheckpoint interface {
+	Filter
+	SaveCheckpoint(io.Writer) error
+	LoadCheckpoint(io.Reader) error
+}
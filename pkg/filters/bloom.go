@@ -0,0 +1,230 @@
+package filters
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"hash/fnv"
+	"io"
+	"math"
+	"sync"
+)
+
+const (
+	// bloomGrowth is the capacity multiple of a new slice when the last one is full.
+	bloomGrowth = 2
+	// bloomTightening is the false positive rate multiple of a new slice,
+	// so the compound rate stays under the configured one.
+	bloomTightening = 0.8
+)
+
+// bloomSlice is a plain bloom filter with a fixed capacity.
+type bloomSlice struct {
+	bits     []uint64
+	m        uint64 // number of bits
+	k        uint64 // number of hash functions
+	count    uint
+	capacity uint
+}
+
+func newBloomSlice(capacity uint, falsePositiveRate float64) *bloomSlice {
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomSlice{
+		bits:     make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+		capacity: capacity,
+	}
+}
+
+func (b *bloomSlice) test(h1, h2 uint64) bool {
+	for i := uint64(0); i < b.k; i++ {
+		n := (h1 + i*h2) % b.m
+		if b.bits[n/64]&(1<<(n%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloomSlice) add(h1, h2 uint64) {
+	for i := uint64(0); i < b.k; i++ {
+		n := (h1 + i*h2) % b.m
+		b.bits[n/64] |= 1 << (n % 64)
+	}
+	b.count++
+}
+
+// BloomFilter is a scalable bloom filter.
+// It uses a fixed amount of memory per slice and adds a larger slice when the last one is full,
+// so it fits crawls with millions of urls. A key may be reported as existing by mistake
+// with the configured false positive rate, but an existing key is never reported as missing.
+type BloomFilter struct {
+	mutex             sync.RWMutex
+	slices            []*bloomSlice
+	capacity          uint
+	falsePositiveRate float64
+	logger            pkg.Logger
+}
+
+// hash returns two hashes of the key for double hashing.
+func (f *BloomFilter) hash(uniqueKey any) (h1, h2 uint64) {
+	h := fnv.New128a()
+	switch v := uniqueKey.(type) {
+	case string:
+		_, _ = h.Write([]byte(v))
+	case []byte:
+		_, _ = h.Write(v)
+	default:
+		_, _ = fmt.Fprint(h, v)
+	}
+	sum := h.Sum(nil)
+	h1 = binary.BigEndian.Uint64(sum[:8])
+	h2 = binary.BigEndian.Uint64(sum[8:]) | 1
+	return
+}
+
+func (f *BloomFilter) IsExist(_ pkg.Context, uniqueKey any) (ok bool, err error) {
+	h1, h2 := f.hash(uniqueKey)
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	for _, s := range f.slices {
+		if s.test(h1, h2) {
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+func (f *BloomFilter) Store(_ pkg.Context, uniqueKey any) (err error) {
+	h1, h2 := f.hash(uniqueKey)
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, s := range f.slices {
+		if s.test(h1, h2) {
+			return
+		}
+	}
+
+	last := f.slices[len(f.slices)-1]
+	if last.count >= last.capacity {
+		last = newBloomSlice(last.capacity*bloomGrowth, f.sliceFalsePositiveRate(len(f.slices)))
+		f.slices = append(f.slices, last)
+	}
+	last.add(h1, h2)
+	return
+}
+
+func (f *BloomFilter) Clean(_ pkg.Context) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.reset()
+	return
+}
+
+// Len returns the approximate number of stored keys.
+func (f *BloomFilter) Len() (l uint) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	for _, s := range f.slices {
+		l += s.count
+	}
+	return
+}
+
+// bloomSliceCheckpoint is the gob encoding of a bloomSlice.
+type bloomSliceCheckpoint struct {
+	Bits     []uint64
+	M        uint64
+	K        uint64
+	Count    uint
+	Capacity uint
+}
+
+func (f *BloomFilter) SaveCheckpoint(w io.Writer) (err error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	slices := make([]bloomSliceCheckpoint, len(f.slices))
+	for i, s := range f.slices {
+		slices[i] = bloomSliceCheckpoint{
+			Bits:     s.bits,
+			M:        s.m,
+			K:        s.k,
+			Count:    s.count,
+			Capacity: s.capacity,
+		}
+	}
+	err = gob.NewEncoder(w).Encode(slices)
+	return
+}
+
+func (f *BloomFilter) LoadCheckpoint(r io.Reader) (err error) {
+	var slices []bloomSliceCheckpoint
+	if err = gob.NewDecoder(r).Decode(&slices); err != nil {
+		return
+	}
+	if len(slices) == 0 {
+		err = errors.New("empty bloom filter checkpoint")
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.slices = make([]*bloomSlice, len(slices))
+	for i, s := range slices {
+		if s.M == 0 || uint64(len(s.Bits)) != (s.M+63)/64 {
+			err = errors.New("invalid bloom filter checkpoint")
+			f.reset()
+			return
+		}
+		f.slices[i] = &bloomSlice{
+			bits:     s.Bits,
+			m:        s.M,
+			k:        s.K,
+			count:    s.Count,
+			capacity: s.Capacity,
+		}
+	}
+	return
+}
+
+// sliceFalsePositiveRate is the rate of the i-th slice, the sum of all slices converges to the configured rate.
+func (f *BloomFilter) sliceFalsePositiveRate(i int) float64 {
+	return f.falsePositiveRate * (1 - bloomTightening) * math.Pow(bloomTightening, float64(i))
+}
+
+func (f *BloomFilter) reset() {
+	f.slices = []*bloomSlice{newBloomSlice(f.capacity, f.sliceFalsePositiveRate(0))}
+}
+
+func (f *BloomFilter) FromSpider(spider pkg.Spider) pkg.Filter {
+	if f == nil {
+		return new(BloomFilter).FromSpider(spider)
+	}
+
+	config := spider.GetConfig()
+	f.capacity = config.GetFilterBloomCapacity()
+	f.falsePositiveRate = config.GetFilterBloomFalsePositiveRate()
+	f.logger = spider.GetLogger()
+	f.reset()
+
+	return f
+}
@@ -0,0 +1,81 @@
+package filters
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	f := &BloomFilter{
+		capacity:          1000,
+		falsePositiveRate: 0.01,
+	}
+	f.reset()
+
+	n := 10000
+	for i := 0; i < n; i++ {
+		if err := f.Store(nil, fmt.Sprintf("https://a/%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(f.slices) < 2 {
+		t.Fatalf("slices %d, should grow", len(f.slices))
+	}
+
+	for i := 0; i < n; i++ {
+		ok, _ := f.IsExist(nil, fmt.Sprintf("https://a/%d", i))
+		if !ok {
+			t.Fatalf("https://a/%d should exist", i)
+		}
+	}
+
+	falsePositive := 0
+	for i := 0; i < n; i++ {
+		ok, _ := f.IsExist(nil, fmt.Sprintf("https://b/%d", i))
+		if ok {
+			falsePositive++
+		}
+	}
+	if rate := float64(falsePositive) / float64(n); rate > 0.01 {
+		t.Fatalf("false positive rate %f", rate)
+	}
+
+	_ = f.Clean(nil)
+	if f.Len() != 0 {
+		t.Fatalf("len %d after clean", f.Len())
+	}
+}
+
+func TestBloomFilterCheckpoint(t *testing.T) {
+	f := &BloomFilter{
+		capacity:          100,
+		falsePositiveRate: 0.01,
+	}
+	f.reset()
+	for i := 0; i < 500; i++ {
+		_ = f.Store(nil, fmt.Sprintf("https://a/%d", i))
+	}
+
+	var buf bytes.Buffer
+	if err := f.SaveCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &BloomFilter{
+		capacity:          100,
+		falsePositiveRate: 0.01,
+	}
+	r.reset()
+	if err := r.LoadCheckpoint(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != f.Len() || len(r.slices) != len(f.slices) {
+		t.Fatalf("len %d, want %d", r.Len(), f.Len())
+	}
+	for i := 0; i < 500; i++ {
+		if ok, _ := r.IsExist(nil, fmt.Sprintf("https://a/%d", i)); !ok {
+			t.Fatalf("https://a/%d should exist", i)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package filters
 
 import (
+	"encoding/json"
 	"github.com/lizongying/go-crawler/pkg"
+	"io"
 	"sync"
 )
 
@@ -28,6 +30,28 @@ func (f *MemoryFilter) Clean(_ pkg.Context) (err error) {
 	return
 }
 
+// SaveCheckpoint writes the keys as a json array.
+func (f *MemoryFilter) SaveCheckpoint(w io.Writer) (err error) {
+	keys := make([]any, 0)
+	f.ids.Range(func(key, _ any) bool {
+		keys = append(keys, key)
+		return true
+	})
+	err = json.NewEncoder(w).Encode(keys)
+	return
+}
+
+func (f *MemoryFilter) LoadCheckpoint(r io.Reader) (err error) {
+	var keys []any
+	if err = json.NewDecoder(r).Decode(&keys); err != nil {
+		return
+	}
+	for _, key := range keys {
+		f.ids.Store(key, struct{}{})
+	}
+	return
+}
+
 func (f *MemoryFilter) FromSpider(spider pkg.Spider) pkg.Filter {
 	if f == nil {
 		return new(MemoryFilter).FromSpider(spider)
@@ -0,0 +1,111 @@
+package filters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+)
+
+// errSqliteNil is returned by the filter if sqlite isn't configured.
+var errSqliteNil = errors.New(`sqlite nil. please check if "sqlite" is configured`)
+
+// SqliteFilter stores the unique keys in a sqlite table, so the filter survives restarts.
+// The table is named by the bot and the spider, and it's never cleaned by the framework.
+type SqliteFilter struct {
+	table  string
+	db     *sql.DB
+	config pkg.Config
+	spider pkg.Spider
+	logger pkg.Logger
+}
+
+func (f *SqliteFilter) SpiderOpened(c pkg.Context) (err error) {
+	if c.GetSpider().GetName() != f.spider.Name() {
+		return
+	}
+	if c.GetSpider().GetStatus() != pkg.SpiderStatusRunning {
+		return
+	}
+
+	if err = f.createTable(context.Background(), fmt.Sprintf("%s_%s_filter", f.config.GetBotName(), f.spider.Name())); err != nil {
+		f.logger.Error(err)
+	}
+	return
+}
+
+func (f *SqliteFilter) createTable(ctx context.Context, table string) (err error) {
+	if f.db == nil {
+		err = errSqliteNil
+		return
+	}
+
+	f.table = table
+	f.logger.Debug("filter table", f.table)
+	_, err = f.db.ExecContext(ctx,
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (`unique_key` TEXT PRIMARY KEY) WITHOUT ROWID", f.table))
+	return
+}
+
+func (f *SqliteFilter) IsExist(c pkg.Context, uniqueKey any) (ok bool, err error) {
+	if f.db == nil {
+		err = errSqliteNil
+		return
+	}
+
+	ctx := c.GetRequest().GetContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var one int
+	err = f.db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT 1 FROM `%s` WHERE `unique_key` = ?", f.table), fmt.Sprint(uniqueKey)).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	ok = true
+	return
+}
+
+func (f *SqliteFilter) Store(c pkg.Context, uniqueKey any) (err error) {
+	if f.db == nil {
+		err = errSqliteNil
+		return
+	}
+
+	ctx := c.GetRequest().GetContext()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	_, err = f.db.ExecContext(ctx,
+		fmt.Sprintf("INSERT OR IGNORE INTO `%s` (`unique_key`) VALUES (?)", f.table), fmt.Sprint(uniqueKey))
+	return
+}
+
+func (f *SqliteFilter) Clean(_ pkg.Context) (err error) {
+	return
+}
+
+func (f *SqliteFilter) FromSpider(spider pkg.Spider) pkg.Filter {
+	if f == nil {
+		return new(SqliteFilter).FromSpider(spider)
+	}
+
+	spider.GetCrawler().GetSignal().RegisterSpiderChanged(f.SpiderOpened)
+
+	f.config = spider.GetConfig()
+	f.spider = spider
+	if sqlite := spider.GetCrawler().GetSqlite(); sqlite != nil {
+		f.db = sqlite.Client()
+	}
+	f.logger = spider.GetLogger()
+	return f
+}
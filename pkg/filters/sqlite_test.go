@@ -0,0 +1,57 @@
+package filters
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lizongying/go-crawler/pkg/config"
+	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	_ "github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"testing"
+)
+
+func TestSqliteFilter(t *testing.T) {
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := new(crawlerContext.Context).WithRequest(new(crawlerContext.Request))
+
+	// sqlite isn't configured
+	f := &SqliteFilter{logger: logger}
+	if err = f.createTable(context.Background(), "test_filter"); !errors.Is(err, errSqliteNil) {
+		t.Errorf("got %v, want %v", err, errSqliteNil)
+	}
+	if _, err = f.IsExist(ctx, "a"); !errors.Is(err, errSqliteNil) {
+		t.Errorf("got %v, want %v", err, errSqliteNil)
+	}
+	if err = f.Store(ctx, "a"); !errors.Is(err, errSqliteNil) {
+		t.Errorf("got %v, want %v", err, errSqliteNil)
+	}
+
+	f.db, err = sql.Open("sqlite3", filepath.Join(t.TempDir(), "filter.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.db.Close()
+	if err = f.createTable(context.Background(), "test_filter"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := f.IsExist(ctx, "a"); err != nil || ok {
+		t.Errorf("got %v %v, want not existing", ok, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = f.Store(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, err := f.IsExist(ctx, "a"); err != nil || !ok {
+		t.Errorf("got %v %v, want existing", ok, err)
+	}
+}
@@ -0,0 +1,15 @@
+package pkg
+
+type HttpCacheStorage string
+
+const (
+	HttpCacheStorageFile   HttpCacheStorage = "file"
+	HttpCacheStorageSqlite HttpCacheStorage = "sqlite"
+)
+
+type HttpCachePolicy string
+
+const (
+	HttpCachePolicyDummy   HttpCachePolicy = "dummy"   // cache every response, ignore the cache headers
+	HttpCachePolicyRfc9111 HttpCachePolicy = "rfc9111" // respect Cache-Control/Expires, revalidate by ETag/Last-Modified
+)
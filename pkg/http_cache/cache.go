@@ -0,0 +1,31 @@
+package http_cache
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNotFound is returned by the storage if the key is not cached.
+var ErrNotFound = errors.New("not found")
+
+// Entry is a cached response.
+type Entry struct {
+	Url        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Time       time.Time   `json:"time"` // when the response is received or revalidated
+}
+
+// Storage stores the entries by the request fingerprint.
+type Storage interface {
+	Get(key string) (*Entry, error)
+	Set(key string, entry *Entry) error
+	Close() error
+}
+
+// Expired returns whether the entry is older than the ttl, 0 means never expire.
+func (e *Entry) Expired(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(e.Time) > ttl
+}
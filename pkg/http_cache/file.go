@@ -0,0 +1,68 @@
+package http_cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileStorage stores every entry as a json file in the dir.
+type FileStorage struct {
+	dir string
+}
+
+func (s *FileStorage) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(s.dir, key+".json")
+	}
+	return filepath.Join(s.dir, key[:2], key+".json")
+}
+
+func (s *FileStorage) Get(key string) (entry *Entry, err error) {
+	bs, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = ErrNotFound
+		}
+		return
+	}
+
+	entry = new(Entry)
+	err = json.Unmarshal(bs, entry)
+	return
+}
+
+func (s *FileStorage) Set(key string, entry *Entry) (err error) {
+	bs, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	path := s.path(key)
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+
+	// write to a temporary file first, so a reader won't get a partial entry
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, bs, 0644); err != nil {
+		return
+	}
+	err = os.Rename(tmp, path)
+	return
+}
+
+func (s *FileStorage) Close() (err error) {
+	return
+}
+
+func NewFileStorage(dir string) (storage *FileStorage, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	storage = &FileStorage{
+		dir: dir,
+	}
+	return
+}
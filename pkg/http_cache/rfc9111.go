@@ -0,0 +1,180 @@
+package http_cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// heuristicStatusCodes are cacheable by default, RFC 9111 section 4.2.2.
+var heuristicStatusCodes = []int{
+	http.StatusOK,
+	http.StatusNonAuthoritativeInfo,
+	http.StatusNoContent,
+	http.StatusPartialContent,
+	http.StatusMultipleChoices,
+	http.StatusMovedPermanently,
+	http.StatusPermanentRedirect,
+	http.StatusNotFound,
+	http.StatusMethodNotAllowed,
+	http.StatusGone,
+	http.StatusRequestURITooLong,
+	http.StatusNotImplemented,
+}
+
+// CacheControl is the parsed Cache-Control header, the directives are lower case.
+type CacheControl map[string]string
+
+func (c CacheControl) Has(directive string) bool {
+	_, ok := c[directive]
+	return ok
+}
+
+// Seconds returns the value of a delta-seconds directive such as max-age.
+func (c CacheControl) Seconds(directive string) (d time.Duration, ok bool) {
+	v, ok := c[directive]
+	if !ok {
+		return
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		ok = false
+		return
+	}
+	d = time.Duration(n) * time.Second
+	return
+}
+
+func ParseCacheControl(header http.Header) (cc CacheControl) {
+	cc = make(CacheControl)
+	for _, line := range header.Values("Cache-Control") {
+		for _, v := range strings.Split(line, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			name, value, _ := strings.Cut(v, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return
+}
+
+// Storable returns whether the response can be stored, RFC 9111 section 3.
+func Storable(method string, requestHeader http.Header, statusCode int, responseHeader http.Header) bool {
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	if ParseCacheControl(requestHeader).Has("no-store") {
+		return false
+	}
+
+	cc := ParseCacheControl(responseHeader)
+	if cc.Has("no-store") {
+		return false
+	}
+	if statusCode == http.StatusPartialContent {
+		// partial content is not combined
+		return false
+	}
+
+	// explicit freshness or validators make the response storable
+	if cc.Has("max-age") || cc.Has("s-maxage") || cc.Has("public") || cc.Has("no-cache") ||
+		responseHeader.Get("Expires") != "" ||
+		responseHeader.Get("ETag") != "" || responseHeader.Get("Last-Modified") != "" {
+		return true
+	}
+	for _, v := range heuristicStatusCodes {
+		if v == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// FreshnessLifetime returns how long the entry is fresh after it was received, RFC 9111 section 4.2.1.
+func (e *Entry) FreshnessLifetime() time.Duration {
+	cc := ParseCacheControl(e.Header)
+	if d, ok := cc.Seconds("max-age"); ok {
+		return d
+	}
+
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.Time
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			// invalid expires means already expired
+			return 0
+		}
+		return t.Sub(date)
+	}
+
+	// heuristic freshness, 10% of the time since last modified
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil {
+		for _, v := range heuristicStatusCodes {
+			if v == e.StatusCode {
+				if d := date.Sub(lastModified); d > 0 {
+					return d / 10
+				}
+				break
+			}
+		}
+	}
+	return 0
+}
+
+// Age returns the current age of the entry, RFC 9111 section 4.2.3.
+func (e *Entry) Age(now time.Time) time.Duration {
+	age := now.Sub(e.Time)
+	if v, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && v > 0 {
+		age += time.Duration(v) * time.Second
+	}
+	return age
+}
+
+// Fresh returns whether the entry can be used without revalidation.
+func (e *Entry) Fresh(requestHeader http.Header, now time.Time) bool {
+	requestCc := ParseCacheControl(requestHeader)
+	if requestCc.Has("no-cache") || requestHeader.Get("Pragma") == "no-cache" {
+		return false
+	}
+	if ParseCacheControl(e.Header).Has("no-cache") {
+		return false
+	}
+
+	age := e.Age(now)
+	lifetime := e.FreshnessLifetime()
+	if d, ok := requestCc.Seconds("max-age"); ok && d < lifetime {
+		lifetime = d
+	}
+	return age < lifetime
+}
+
+// SetValidators adds the conditional headers to the request, ok is false if the entry has no validator.
+func (e *Entry) SetValidators(request *http.Request) (ok bool) {
+	if etag := e.Header.Get("ETag"); etag != "" {
+		request.Header.Set("If-None-Match", etag)
+		ok = true
+	}
+	if lastModified := e.Header.Get("Last-Modified"); lastModified != "" {
+		request.Header.Set("If-Modified-Since", lastModified)
+		ok = true
+	}
+	return
+}
+
+// Revalidated updates the entry by a 304 response, RFC 9111 section 4.3.4.
+func (e *Entry) Revalidated(header http.Header, now time.Time) {
+	for k, v := range header {
+		switch http.CanonicalHeaderKey(k) {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue
+		}
+		e.Header[k] = v
+	}
+	e.Time = now
+}
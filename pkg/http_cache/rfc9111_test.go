@@ -0,0 +1,51 @@
+package http_cache
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEntryFresh(t *testing.T) {
+	now := time.Now()
+	date := now.Add(-time.Minute).UTC().Format(http.TimeFormat)
+
+	for _, v := range []struct {
+		name   string
+		header http.Header
+		fresh  bool
+	}{
+		{"max-age", http.Header{"Cache-Control": {"public, max-age=3600"}}, true},
+		{"max-age expired", http.Header{"Cache-Control": {"max-age=30"}}, false},
+		{"no-cache", http.Header{"Cache-Control": {"no-cache, max-age=3600"}}, false},
+		{"expires", http.Header{"Date": {date}, "Expires": {now.Add(time.Hour).UTC().Format(http.TimeFormat)}}, true},
+		{"invalid expires", http.Header{"Date": {date}, "Expires": {"0"}}, false},
+		{"heuristic", http.Header{"Date": {date}, "Last-Modified": {now.Add(-100 * time.Hour).UTC().Format(http.TimeFormat)}}, true},
+		{"age", http.Header{"Cache-Control": {"max-age=100"}, "Age": {"50"}}, false},
+		{"none", http.Header{}, false},
+	} {
+		e := &Entry{
+			StatusCode: http.StatusOK,
+			Header:     v.header,
+			Time:       now.Add(-time.Minute),
+		}
+		if fresh := e.Fresh(http.Header{}, now); fresh != v.fresh {
+			t.Errorf("%s: fresh %v, want %v", v.name, fresh, v.fresh)
+		}
+	}
+}
+
+func TestStorable(t *testing.T) {
+	if Storable(http.MethodPost, http.Header{}, http.StatusOK, http.Header{"Cache-Control": {"max-age=60"}}) {
+		t.Error("post should not be stored")
+	}
+	if Storable(http.MethodGet, http.Header{}, http.StatusOK, http.Header{"Cache-Control": {"no-store"}}) {
+		t.Error("no-store should not be stored")
+	}
+	if Storable(http.MethodGet, http.Header{}, http.StatusInternalServerError, http.Header{}) {
+		t.Error("500 without explicit freshness should not be stored")
+	}
+	if !Storable(http.MethodGet, http.Header{}, http.StatusOK, http.Header{}) {
+		t.Error("200 should be stored")
+	}
+}
@@ -0,0 +1,62 @@
+package http_cache
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SqliteStorage stores the entries in a sqlite table.
+type SqliteStorage struct {
+	db    *sql.DB
+	table string
+}
+
+func (s *SqliteStorage) Get(key string) (entry *Entry, err error) {
+	var bs []byte
+	err = s.db.QueryRow(fmt.Sprintf("SELECT `entry` FROM `%s` WHERE `key` = ?", s.table), key).Scan(&bs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrNotFound
+		}
+		return
+	}
+
+	entry = new(Entry)
+	err = json.Unmarshal(bs, entry)
+	return
+}
+
+func (s *SqliteStorage) Set(key string, entry *Entry) (err error) {
+	bs, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf("INSERT OR REPLACE INTO `%s` (`key`, `entry`) VALUES (?, ?)", s.table), key, bs)
+	return
+}
+
+// Close does nothing, the db is shared with others.
+func (s *SqliteStorage) Close() (err error) {
+	return
+}
+
+func NewSqliteStorage(db *sql.DB, table string) (storage *SqliteStorage, err error) {
+	if db == nil {
+		err = errors.New("sqlite nil")
+		return
+	}
+
+	_, err = db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (`key` TEXT PRIMARY KEY, `entry` BLOB) WITHOUT ROWID", table))
+	if err != nil {
+		return
+	}
+
+	storage = &SqliteStorage{
+		db:    db,
+		table: table,
+	}
+	return
+}
@@ -0,0 +1,135 @@
+package browser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/media"
+	"github.com/lizongying/go-crawler/pkg/utils"
+	"io"
+	"strconv"
+	"time"
+)
+
+// requestIdle is how long there's no network request, that the network is idle.
+const requestIdle = 500 * time.Millisecond
+
+// runActions runs the actions of the request in order, it stops at the first failed action.
+func (b *Browser) runActions(page *rod.Page, request pkg.Request, response pkg.Response) (err error) {
+	timeout := b.timeout
+	if request.GetTimeout() > 0 {
+		timeout = request.GetTimeout()
+	}
+
+	for i, action := range request.GetActions() {
+		actionTimeout := timeout
+		if action.Timeout > 0 {
+			actionTimeout = action.Timeout
+		}
+		actionPage := page.Timeout(actionTimeout)
+		err = b.runAction(actionPage, request, response, i, action)
+		actionPage.CancelTimeout()
+		if err != nil {
+			err = fmt.Errorf("action %d %s: %w", i, action.Type, err)
+			return
+		}
+	}
+	return
+}
+
+func (b *Browser) runAction(page *rod.Page, request pkg.Request, response pkg.Response, index int, action pkg.Action) (err error) {
+	switch action.Type {
+	case pkg.ActionTypeWaitSelector:
+		var el *rod.Element
+		if el, err = page.Element(action.Selector); err != nil {
+			return
+		}
+		err = el.WaitVisible()
+	case pkg.ActionTypeWaitIdle:
+		page.WaitRequestIdle(requestIdle, nil, nil, nil)()
+	case pkg.ActionTypeClick:
+		var el *rod.Element
+		if el, err = page.Element(action.Selector); err != nil {
+			return
+		}
+		err = el.Click(proto.InputMouseButtonLeft, 1)
+	case pkg.ActionTypeType:
+		var el *rod.Element
+		if el, err = page.Element(action.Selector); err != nil {
+			return
+		}
+		err = el.Input(action.Text)
+	case pkg.ActionTypeScroll:
+		times := max(int(action.Times), 1)
+		for i := 0; i < times; i++ {
+			// wait for the content loaded by the scrolling, the waiter is set before, so it sees the requests
+			wait := page.WaitRequestIdle(requestIdle, nil, nil, nil)
+			if _, err = page.Eval(`() => window.scrollTo(0, document.body.scrollHeight)`); err != nil {
+				return
+			}
+			wait()
+		}
+	case pkg.ActionTypeEval:
+		var res *proto.RuntimeRemoteObject
+		if res, err = page.Eval(action.Script); err != nil {
+			return
+		}
+		response.SetEvalResults(append(response.EvalResults(), res.Value.Val()))
+	case pkg.ActionTypeScreenshot:
+		var body []byte
+		if body, err = page.Screenshot(action.FullPage, &proto.PageCaptureScreenshot{
+			Format: proto.PageCaptureScreenshotFormatPng,
+		}); err != nil {
+			return
+		}
+		err = b.store(request, response, index, "png", bytes.NewReader(body))
+	case pkg.ActionTypePdf:
+		var reader *rod.StreamReader
+		if reader, err = page.PDF(&proto.PagePrintToPDF{
+			PrintBackground: true,
+		}); err != nil {
+			return
+		}
+		err = errors.Join(b.store(request, response, index, "pdf", reader), reader.Close())
+	default:
+		err = errors.New("action not supported")
+	}
+	return
+}
+
+// store saves the file of the action by the store of the crawler, and adds it to the files of the response.
+func (b *Browser) store(request pkg.Request, response pkg.Response, index int, ext string, body io.Reader) (err error) {
+	if b.spider == nil || b.spider.GetCrawler().GetStore() == nil {
+		err = errors.New("store nil")
+		return
+	}
+
+	// the id keeps the files of the requests to the same url apart
+	id := ""
+	if ctx := request.GetContext(); ctx != nil && ctx.GetRequest() != nil {
+		id = ctx.GetRequest().GetId()
+	}
+	if id == "" {
+		id = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	name := fmt.Sprintf("%s-%s-%d", utils.StrMd5(request.GetUrl()), id, index)
+	hash := sha256.New()
+	storePath, err := b.spider.GetCrawler().GetStore().SaveReader("", fmt.Sprintf("%s.%s", name, ext), io.TeeReader(body, hash))
+	if err != nil {
+		return
+	}
+
+	file := new(media.File)
+	file.SetUrl(request.GetUrl())
+	file.SetName(name)
+	file.SetExt(ext)
+	file.SetStorePath(storePath)
+	file.SetSha256(hex.EncodeToString(hash.Sum(nil)))
+	response.SetFiles(append(response.Files(), file))
+	return
+}
@@ -0,0 +1,54 @@
+package browser
+
+import (
+	"context"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/config"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBrowser_DoRequestActionFailed(t *testing.T) {
+	if _, ok := launcher.LookPath(); !ok {
+		t.Skip("no browser")
+	}
+
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewBrowser(logger, Options{Timeout: time.Minute, Headless: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = b.Close(nil)
+	}()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>ok</body></html>"))
+	}))
+	defer srv.Close()
+
+	response, err := b.DoRequest(context.Background(), request.NewRequest().
+		SetUrl(srv.URL).
+		SetActions(pkg.Action{
+			Type:   pkg.ActionTypeEval,
+			Script: `() => { throw new Error("action failed") }`,
+		}))
+	if err == nil {
+		t.Fatal("the error of the action should reach the caller")
+	}
+	if response != nil {
+		t.Errorf("got %v, want no response", response)
+	}
+}
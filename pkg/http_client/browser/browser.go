@@ -3,11 +3,13 @@ package browser
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/go-rod/rod/lib/devices"
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/launcher/flags"
 	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/device"
 	response2 "github.com/lizongying/go-crawler/pkg/response"
-	"github.com/lizongying/go-crawler/pkg/utils"
 	"net/http"
 	"net/url"
 	"strings"
@@ -38,24 +40,107 @@ var browserFlags = []string{
 	"--no-zygote",
 }
 
+// resourceTypes are the resource types that can be blocked, by the lower-case names in the config.
+var resourceTypes = []proto.NetworkResourceType{
+	proto.NetworkResourceTypeDocument,
+	proto.NetworkResourceTypeStylesheet,
+	proto.NetworkResourceTypeImage,
+	proto.NetworkResourceTypeMedia,
+	proto.NetworkResourceTypeFont,
+	proto.NetworkResourceTypeScript,
+	proto.NetworkResourceTypeTextTrack,
+	proto.NetworkResourceTypeXHR,
+	proto.NetworkResourceTypeFetch,
+	proto.NetworkResourceTypePrefetch,
+	proto.NetworkResourceTypeEventSource,
+	proto.NetworkResourceTypeWebSocket,
+	proto.NetworkResourceTypeManifest,
+	proto.NetworkResourceTypeSignedExchange,
+	proto.NetworkResourceTypePing,
+	proto.NetworkResourceTypeCSPViolationReport,
+	proto.NetworkResourceTypePreflight,
+	proto.NetworkResourceTypeOther,
+}
+
+// aliveTimeout is the timeout of the health check.
+const aliveTimeout = 5 * time.Second
+
+// Options are the options to launch or connect a browser.
+type Options struct {
+	Proxy     *url.URL
+	Timeout   time.Duration
+	Headless  bool
+	Flags     []string // extra flags
+	Block     []string // resource types to block, e.g. image
+	Remote    string   // the cdp endpoint to connect instead of launching
+	UserAgent string   // the user agent of the requests without it
+}
+
 type Browser struct {
 	proxy        *url.URL
 	timeout      time.Duration
+	headless     bool
+	flags        []string
+	block        []proto.NetworkResourceType
+	remote       string
+	userAgent    string
 	cancel       context.CancelFunc
 	browser      *rod.Browser
 	hijackRouter *rod.HijackRouter
 	logger       pkg.Logger
 	launcher     *Launcher
+	spider       pkg.Spider
+	lastUsed     time.Time
 }
 
 func (b *Browser) init() (err error) {
+	if b.remote != "" {
+		err = b.connect()
+	} else {
+		err = b.launch()
+	}
+	if err != nil {
+		b.logger.Error(err)
+		return
+	}
+
+	if err = b.browser.IgnoreCertErrors(true); err != nil {
+		b.logger.Error(err)
+		err = b.Close(nil)
+		if err != nil {
+			b.logger.Error(err)
+			return
+		}
+
+		return
+	}
+
+	if len(b.block) > 0 {
+		b.hijackRouter = b.browser.HijackRequests()
+		for _, resourceType := range b.block {
+			if err = b.hijackRouter.Add("*", resourceType, func(ctx *rod.Hijack) {
+				ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+			}); err != nil {
+				b.logger.Error(err)
+				return
+			}
+		}
+		go b.hijackRouter.Run()
+	}
+
+	b.lastUsed = time.Now()
+	return
+}
+
+// launch launches a local browser.
+func (b *Browser) launch() (err error) {
 	l := &Launcher{
 		Launcher: launcher.New().
 			//StartURL("").
 			NoSandbox(true).
 			Env("TZ=Asia/Shanghai").
 			Leakless(true).
-			Headless(true),
+			Headless(b.headless),
 	}
 	ctx := context.Background()
 	ctx, b.cancel = context.WithTimeout(ctx, b.timeout)
@@ -71,79 +156,131 @@ func (b *Browser) init() (err error) {
 		l.Proxy(b.proxy.String())
 	}
 
-	if len(browserFlags) != 0 {
-		for _, flag := range browserFlags {
-			l.Set(flags.Flag(strings.TrimLeft(flag, "-")))
+	for _, flag := range append(browserFlags, b.flags...) {
+		name, value, ok := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+		if ok {
+			l.Set(flags.Flag(name), value)
+		} else {
+			l.Set(flags.Flag(name))
 		}
 	}
 
 	u, err := l.Launch()
 	if err != nil {
-		b.logger.Error(err)
 		return
 	}
 
 	b.launcher = l
 	b.browser = rod.New().ControlURL(u)
-	if err = b.browser.Connect(); err != nil {
-		b.logger.Error(err)
+	err = b.browser.Connect()
+	return
+}
+
+// connect connects to a remote browser by the cdp endpoint, e.g. ws://127.0.0.1:9222 or http://127.0.0.1:9222.
+func (b *Browser) connect() (err error) {
+	u, err := launcher.ResolveURL(b.remote)
+	if err != nil {
 		return
 	}
 
-	if err = b.browser.IgnoreCertErrors(true); err != nil {
-		b.logger.Error(err)
-		err = b.Close(nil)
-		if err != nil {
-			b.logger.Error(err)
-			return
-		}
+	var ctx context.Context
+	ctx, b.cancel = context.WithCancel(context.Background())
+	b.browser = rod.New().Context(ctx).ControlURL(u)
+	err = b.browser.Connect()
+	return
+}
 
-		return
+// alive reports whether the browser responds, it's false if the browser crashed or the connection is lost.
+func (b *Browser) alive() bool {
+	if b == nil || b.browser == nil {
+		return false
 	}
+	_, err := proto.BrowserGetVersion{}.Call(b.browser.Timeout(aliveTimeout))
+	return err == nil
+}
 
-	b.hijackRouter = b.browser.HijackRequests()
-	b.hijackRouter.MustAdd("*.png|jpg|jpeg|gif|mp4|webm|avi|wav|mp3", func(ctx *rod.Hijack) {
-		if utils.InSlice(ctx.Request.Type(), []proto.NetworkResourceType{
-			proto.NetworkResourceTypeImage,
-			proto.NetworkResourceTypeMedia,
-		}) {
-			ctx.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
-			return
+// contextBrowser returns an incognito browser context, with the proxy of the request if it's different from the browser's.
+// The request with a cookie jar gets its own context, so the cookies of the jars don't mix in the shared browser.
+// The context should be closed after the request.
+func (b *Browser) contextBrowser(request pkg.Request, jar pkg.CookieJar) (browser *rod.Browser, err error) {
+	createContext := proto.TargetCreateBrowserContext{
+		DisposeOnDetach: true,
+	}
+	proxy := request.GetProxy()
+	if proxy != nil && (b.proxy == nil || proxy.String() != b.proxy.String()) {
+		if proxy.User != nil {
+			b.logger.Warn("the auth of the proxy isn't supported by the browser:", proxy.Redacted())
 		}
-		ctx.ContinueRequest(&proto.FetchContinueRequest{})
-	})
-	go b.hijackRouter.Run()
+		createContext.ProxyServer = (&url.URL{Scheme: proxy.Scheme, Host: proxy.Host}).String()
+	} else if jar == nil {
+		return
+	}
 
+	res, err := createContext.Call(b.browser)
+	if err != nil {
+		return
+	}
+
+	browser = b.browser.Context(b.browser.GetContext())
+	browser.BrowserContextID = res.BrowserContextID
 	return
 }
 
+// emulate sets the user agent of the page, the mobiles are emulated with the screen and touch.
+func emulate(page *rod.Page, userAgent string) error {
+	var d devices.Device
+	switch device.PlatformOf(userAgent) {
+	case pkg.PlatformIphone:
+		d = devices.IPhoneX
+	case pkg.PlatformIpad:
+		d = devices.IPad
+	case pkg.PlatformAndroid:
+		d = devices.Pixel2
+	default:
+		return page.SetUserAgent(&proto.NetworkSetUserAgentOverride{UserAgent: userAgent})
+	}
+	d.UserAgent = userAgent
+	return page.Emulate(d)
+}
+
 func (b *Browser) DoRequest(ctx context.Context, request pkg.Request) (response pkg.Response, err error) {
 	if b == nil {
 		err = errors.New("browser nil")
 		return
 	}
 
-	page, err := stealth.Page(b.browser)
+	browser := b.browser
+	jar := b.cookieJar(request)
+	contextBrowser, err := b.contextBrowser(request, jar)
+	if err != nil {
+		b.logger.Error(err)
+		return
+	}
+	if contextBrowser != nil {
+		browser = contextBrowser
+		defer func() {
+			if e := contextBrowser.Close(); e != nil {
+				b.logger.Error(e)
+			}
+		}()
+	}
+
+	page, err := stealth.Page(browser)
 	if err != nil {
 		b.logger.Error(err)
 		return
 	}
 
+	// the errors of the cleanup are only logged, they mustn't replace the error of the request
 	defer func() {
-		err = proto.NetworkClearBrowserCache{}.Call(page)
-		if err != nil {
-			b.logger.Error(err)
-		}
-		err = proto.NetworkClearBrowserCookies{}.Call(page)
-		if err != nil {
-			b.logger.Error(err)
+		if e := (proto.NetworkClearBrowserCache{}).Call(page); e != nil {
+			b.logger.Error(e)
 		}
-		if page == nil {
-			return
+		if e := (proto.NetworkClearBrowserCookies{}).Call(page); e != nil {
+			b.logger.Error(e)
 		}
-		err = page.Close()
-		if err != nil {
-			b.logger.Error(err)
+		if e := page.Close(); e != nil {
+			b.logger.Error(e)
 		}
 	}()
 
@@ -154,6 +291,10 @@ func (b *Browser) DoRequest(ctx context.Context, request pkg.Request) (response
 		Url = request.GetReferrer()
 	} else {
 		for k := range request.Headers() {
+			// the user agent is emulated
+			if strings.EqualFold(k, "User-Agent") {
+				continue
+			}
 			page.MustSetExtraHeaders(k, request.GetHeader(k))
 		}
 
@@ -161,22 +302,62 @@ func (b *Browser) DoRequest(ctx context.Context, request pkg.Request) (response
 			page.MustSetCookies(&proto.NetworkCookieParam{
 				Name:  v.Name,
 				Value: v.Value,
+				URL:   request.GetUrl(),
 			})
 		}
 	}
+	userAgent := request.GetHeader("User-Agent")
+	if userAgent == "" {
+		userAgent = b.userAgent
+	}
+	if userAgent != "" {
+		if err = emulate(page, userAgent); err != nil {
+			b.logger.Error(err)
+			return
+		}
+	}
+	// the empty cookies would clear the cookies of the browser, including the ones of the request
+	if params := jarCookies(jar); len(params) > 0 {
+		if err = page.SetCookies(params); err != nil {
+			b.logger.Error(err)
+			return
+		}
+	}
+	c, err := startCapture(page, request)
+	if err != nil {
+		b.logger.Error(err)
+		return
+	}
+	// the captures are set after the actions, and the events are released even if the request fails
+	defer func() {
+		captures, e := c.stop(page)
+		if e != nil {
+			b.logger.Error(e)
+		}
+		if response != nil {
+			response.SetCaptures(captures)
+		}
+	}()
+
 	//wait := page.WaitNavigation(proto.PageLifecycleEventNameNetworkIdle)
 	if err = page.Navigate(Url); err != nil {
 		b.logger.Error(err)
 		return
 	}
 
-	//wait()
-	time.Sleep(2 * time.Second)
-
 	response = new(response2.Response)
 	response.SetRequest(request)
 	response.SetResponse(new(http.Response))
 
+	if len(request.GetActions()) == 0 {
+		//wait()
+		time.Sleep(2 * time.Second)
+	} else if err = b.runActions(page, request, response); err != nil {
+		b.logger.Error(err)
+		response = nil
+		return
+	}
+
 	if request.IsAjax() {
 		headers := make(map[string]string)
 		for k := range request.Headers() {
@@ -221,6 +402,14 @@ func (b *Browser) DoRequest(ctx context.Context, request pkg.Request) (response
 
 		response.SetStatusCode(res.Value.Get("status").Int())
 		response.SetBodyBytes([]byte(res.Value.Get("body").Str()))
+		if jar != nil {
+			cookies, e := page.Cookies([]string{})
+			if e != nil {
+				b.logger.Error(e)
+				return
+			}
+			jar.Add(pageCookies(cookies)...)
+		}
 		return
 	}
 
@@ -237,6 +426,9 @@ func (b *Browser) DoRequest(ctx context.Context, request pkg.Request) (response
 		return
 	}
 
+	if jar != nil {
+		jar.Add(pageCookies(cookies)...)
+	}
 	for _, c := range cookies {
 		response.SetCookies(&http.Cookie{
 			Name:       c.Name,
@@ -254,6 +446,64 @@ func (b *Browser) DoRequest(ctx context.Context, request pkg.Request) (response
 	return
 }
 
+// cookieJar returns the cookie jar of the request, it's nil if the cookie middleware isn't enabled.
+func (b *Browser) cookieJar(request pkg.Request) pkg.CookieJar {
+	if b.spider == nil {
+		return nil
+	}
+	return b.spider.GetCookieJar(request.GetCookieJar())
+}
+
+// jarCookies converts the cookies of the jar to the cookies of the browser.
+// The host-only cookies are set by url, as the browser treats a domain as including the subdomains.
+func jarCookies(jar pkg.CookieJar) (params []*proto.NetworkCookieParam) {
+	if jar == nil {
+		return
+	}
+	for _, c := range jar.All() {
+		param := &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+		if strings.HasPrefix(c.Domain, ".") {
+			param.Domain = c.Domain
+		} else {
+			scheme := "http"
+			if c.Secure {
+				scheme = "https"
+			}
+			param.URL = scheme + "://" + c.Domain + c.Path
+		}
+		if !c.Expires.IsZero() {
+			param.Expires = proto.TimeSinceEpoch(c.Expires.Unix())
+		}
+		params = append(params, param)
+	}
+	return
+}
+
+// pageCookies converts the cookies of the browser to the cookies to add to the jar.
+func pageCookies(cookies []*proto.NetworkCookie) (jarCookies []*http.Cookie) {
+	for _, c := range cookies {
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HTTPOnly,
+		}
+		if !c.Session {
+			cookie.Expires = c.Expires.Time()
+		}
+		jarCookies = append(jarCookies, cookie)
+	}
+	return
+}
+
 func (b *Browser) Close(_ context.Context) (err error) {
 	if b == nil {
 		err = errors.New("browser nil")
@@ -265,11 +515,19 @@ func (b *Browser) Close(_ context.Context) (err error) {
 		b.hijackRouter = nil
 	}
 
+	// the remote browser is kept, only the connection is closed
+	if b.launcher == nil {
+		if b.cancel != nil {
+			b.cancel()
+		}
+		return
+	}
+
 	if err = b.browser.Close(); err != nil {
 		b.logger.Error(err)
-		return
 	}
 
+	// the crashed browser is killed and cleaned up as well
 	if b.launcher.Has(flags.Leakless) {
 		b.launcher.Kill()
 	}
@@ -281,11 +539,18 @@ func (b *Browser) Close(_ context.Context) (err error) {
 	return
 }
 
-func NewBrowser(logger pkg.Logger, proxy *url.URL, timeout time.Duration) (b *Browser, err error) {
+func NewBrowser(logger pkg.Logger, options Options) (b *Browser, err error) {
 	b = &Browser{
-		logger:  logger,
-		proxy:   proxy,
-		timeout: timeout,
+		logger:    logger,
+		proxy:     options.Proxy,
+		timeout:   options.Timeout,
+		headless:  options.Headless,
+		flags:     options.Flags,
+		remote:    options.Remote,
+		userAgent: options.UserAgent,
+	}
+	if b.block, err = blockTypes(options.Block); err != nil {
+		return
 	}
 
 	if err = b.init(); err != nil {
@@ -296,17 +561,44 @@ func NewBrowser(logger pkg.Logger, proxy *url.URL, timeout time.Duration) (b *Br
 	return
 }
 
+// blockTypes converts the names of the resource types, e.g. image, case-insensitive.
+func blockTypes(names []string) (types []proto.NetworkResourceType, err error) {
+	for _, name := range names {
+		found := false
+		for _, resourceType := range resourceTypes {
+			if strings.EqualFold(name, string(resourceType)) {
+				types = append(types, resourceType)
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("resource type not supported: %s", name)
+			return
+		}
+	}
+	return
+}
+
 func (b *Browser) FromSpider(spider pkg.Spider) *Browser {
 	if b == nil {
 		return new(Browser).FromSpider(spider)
 	}
 
 	b.logger = spider.GetLogger()
+	b.spider = spider
 	config := spider.GetCrawler().GetConfig()
 	b.proxy = config.GetProxy()
 	b.timeout = config.GetRequestTimeout()
+	b.headless = config.GetBrowserHeadless()
+	b.flags = config.GetBrowserFlags()
+	b.remote = config.GetBrowserRemote()
+	var err error
+	if b.block, err = blockTypes(config.GetBrowserBlock()); err != nil {
+		b.logger.Error(err)
+	}
 
-	err := b.init()
+	err = b.init()
 	if err != nil {
 		b.logger.Error(err)
 	}
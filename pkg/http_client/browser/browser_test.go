@@ -0,0 +1,22 @@
+package browser
+
+import (
+	"github.com/go-rod/rod/lib/proto"
+	"reflect"
+	"testing"
+)
+
+func TestBlockTypes(t *testing.T) {
+	types, err := blockTypes([]string{"image", "Media", "XHR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []proto.NetworkResourceType{proto.NetworkResourceTypeImage, proto.NetworkResourceTypeMedia, proto.NetworkResourceTypeXHR}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("got %v, want %v", types, want)
+	}
+
+	if _, err = blockTypes([]string{"video"}); err == nil {
+		t.Error("unknown resource type should fail")
+	}
+}
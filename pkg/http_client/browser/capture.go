@@ -0,0 +1,142 @@
+package browser
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/lizongying/go-crawler/pkg"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type captured struct {
+	capture  *pkg.Capture
+	finished bool
+}
+
+// capturer records the xhr/fetch responses of the page, whose urls match the patterns.
+type capturer struct {
+	mutex    sync.Mutex
+	patterns []*regexp.Regexp
+	methods  map[proto.NetworkRequestID]string
+	captured map[proto.NetworkRequestID]*captured
+	ids      []proto.NetworkRequestID
+	cancel   context.CancelFunc
+}
+
+// startCapture starts to record the responses matching the captures of the request, it's nil if there's no capture.
+func startCapture(page *rod.Page, request pkg.Request) (c *capturer, err error) {
+	if len(request.GetCaptures()) == 0 {
+		return
+	}
+
+	c = &capturer{
+		methods:  make(map[proto.NetworkRequestID]string),
+		captured: make(map[proto.NetworkRequestID]*captured),
+	}
+	for _, capture := range request.GetCaptures() {
+		var pattern *regexp.Regexp
+		if pattern, err = regexp.Compile(capture); err != nil {
+			c = nil
+			return
+		}
+		c.patterns = append(c.patterns, pattern)
+	}
+
+	ctx, cancel := context.WithCancel(page.GetContext())
+	c.cancel = cancel
+	wait := page.Context(ctx).EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			c.methods[e.RequestID] = e.Request.Method
+		},
+		func(e *proto.NetworkResponseReceived) {
+			if e.Type != proto.NetworkResourceTypeXHR && e.Type != proto.NetworkResourceTypeFetch {
+				return
+			}
+			if !c.match(e.Response.URL) {
+				return
+			}
+
+			headers := make(http.Header)
+			for k, v := range e.Response.Headers {
+				// the values of the same header are joined by newlines
+				for _, value := range strings.Split(v.Str(), "\n") {
+					headers.Add(k, value)
+				}
+			}
+
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			if _, ok := c.captured[e.RequestID]; !ok {
+				c.ids = append(c.ids, e.RequestID)
+			}
+			c.captured[e.RequestID] = &captured{
+				capture: &pkg.Capture{
+					Url:     e.Response.URL,
+					Method:  c.methods[e.RequestID],
+					Status:  e.Response.Status,
+					Headers: headers,
+				},
+			}
+		},
+		func(e *proto.NetworkLoadingFinished) {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+			if v, ok := c.captured[e.RequestID]; ok {
+				v.finished = true
+			}
+		},
+	)
+	go wait()
+	return
+}
+
+func (c *capturer) match(url string) bool {
+	for _, pattern := range c.patterns {
+		if pattern.MatchString(url) {
+			return true
+		}
+	}
+	return false
+}
+
+// stop stops the recording, and returns the captured responses in the order they were received.
+// The bodies are got before the network domain is disabled, the unfinished responses have no body,
+// the captures are returned with the errors of getting the bodies.
+func (c *capturer) stop(page *rod.Page) (captures []*pkg.Capture, err error) {
+	if c == nil {
+		return
+	}
+	defer c.cancel()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, id := range c.ids {
+		v := c.captured[id]
+		captures = append(captures, v.capture)
+		if !v.finished {
+			continue
+		}
+
+		// the body may be evicted by the browser, the capture is kept without the body
+		res, e := proto.NetworkGetResponseBody{RequestID: id}.Call(page)
+		if e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		if !res.Base64Encoded {
+			v.capture.Body = []byte(res.Body)
+			continue
+		}
+		if v.capture.Body, e = base64.StdEncoding.DecodeString(res.Body); e != nil {
+			err = errors.Join(err, e)
+		}
+	}
+	return
+}
@@ -5,19 +5,31 @@ import (
 	"errors"
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/device"
+	"github.com/lizongying/go-crawler/static"
+	"math/rand"
+	"sync"
+	"time"
 )
 
-const PoolSize = 2
-
 type Launcher struct {
 	*launcher.Launcher
 	managed bool
 }
 
+// Manager keeps a pool of browsers, which are launched on demand up to the pool size.
+// The crashed browsers are closed and relaunched by the next request, and the idle ones are closed after the idle timeout.
 type Manager struct {
-	pool   chan *Browser
-	logger pkg.Logger
-	spider pkg.Spider
+	idle        chan *Browser
+	slots       chan struct{} // the browsers in use
+	done        chan struct{}
+	mutex       sync.Mutex
+	closed      bool
+	logger      pkg.Logger
+	spider      pkg.Spider
+	options     Options
+	idleTimeout time.Duration
+	userAgents  []string
 }
 
 func (m *Manager) FromSpider(spider pkg.Spider) *Manager {
@@ -26,51 +38,171 @@ func (m *Manager) FromSpider(spider pkg.Spider) *Manager {
 	}
 
 	m.logger = spider.GetLogger()
+	m.spider = spider
 	config := spider.GetCrawler().GetConfig()
 
-	poolSize := PoolSize
-	m.pool = make(chan *Browser, poolSize)
-	for i := 0; i < poolSize; i++ {
-		browser, err := NewBrowser(m.logger, config.GetProxy(), config.GetRequestTimeout())
-		if err != nil {
-			m.logger.Error(err)
-			continue
+	poolSize := int(config.GetBrowserPoolSize())
+	m.idle = make(chan *Browser, poolSize)
+	m.slots = make(chan struct{}, poolSize)
+	m.done = make(chan struct{})
+	m.options = Options{
+		Proxy:    config.GetProxy(),
+		Timeout:  config.GetRequestTimeout(),
+		Headless: config.GetBrowserHeadless(),
+		Flags:    config.GetBrowserFlags(),
+		Block:    config.GetBrowserBlock(),
+		Remote:   config.GetBrowserRemote(),
+	}
+	m.idleTimeout = config.GetBrowserIdleTimeout()
+
+	// the browser is chrome, so the user agents of chrome are used
+	devices, _ := device.NewDevicesFromBytes(static.Devices)
+	for _, platform := range []pkg.Platform{pkg.PlatformWindows, pkg.PlatformMac} {
+		for _, d := range devices.Devices[string(platform)+"-"+string(pkg.BrowserChrome)] {
+			m.userAgents = append(m.userAgents, d.UserAgent)
 		}
-		m.pool <- browser
+	}
+
+	if m.idleTimeout > 0 {
+		go m.recycle()
 	}
 
 	return m
 }
 
+// launch launches a browser with a random user agent, which is kept for the lifetime of the browser.
+func (m *Manager) launch() (browser *Browser, err error) {
+	options := m.options
+	if len(m.userAgents) > 0 {
+		options.UserAgent = m.userAgents[rand.Intn(len(m.userAgents))]
+	}
+	if browser, err = NewBrowser(m.logger, options); err != nil {
+		if browser != nil {
+			_ = browser.Close(nil)
+		}
+		return
+	}
+	browser.spider = m.spider
+	return
+}
+
 func (m *Manager) Pop(ctx context.Context) (browser *Browser, err error) {
-	var ok bool
 	select {
 	case <-ctx.Done():
 		err = errors.New("manager timeout")
 		return
+	case <-m.done:
+		err = errors.New("manager closed")
+		return
+	case m.slots <- struct{}{}:
+	}
 
-	case browser, ok = <-m.pool:
-		if !ok {
-			err = errors.New("manager closed")
+	for {
+		var ok bool
+		select {
+		case browser, ok = <-m.idle:
+			if !ok {
+				<-m.slots
+				err = errors.New("manager closed")
+				return
+			}
+			if !browser.alive() {
+				m.logger.Warn("browser crashed, relaunch")
+				m.close(browser)
+				continue
+			}
 			return
+		default:
+		}
+
+		if browser, err = m.launch(); err != nil {
+			<-m.slots
 		}
 		return
 	}
 }
 
 func (m *Manager) Put(b *Browser) {
-	m.pool <- b
+	defer func() {
+		<-m.slots
+	}()
+
+	if !b.alive() {
+		m.logger.Warn("browser crashed, relaunch")
+		m.close(b)
+		return
+	}
+
+	b.lastUsed = time.Now()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.closed {
+		m.close(b)
+		return
+	}
+	select {
+	case m.idle <- b:
+	default:
+		m.close(b)
+	}
 }
 
-func (m *Manager) Close() {
-	for len(m.pool) > 0 {
-		if b := <-m.pool; b != nil {
-			ctx := context.Background()
-			if err := b.Close(ctx); err != nil {
-				m.logger.Error(err)
-				continue
+// recycle closes the browsers idle longer than the idle timeout.
+func (m *Manager) recycle() {
+	ticker := time.NewTicker(max(m.idleTimeout/2, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		m.mutex.Lock()
+		var kept []*Browser
+	loop:
+		for {
+			select {
+			case b := <-m.idle:
+				if time.Since(b.lastUsed) < m.idleTimeout {
+					kept = append(kept, b)
+					continue
+				}
+				m.logger.Debug("browser idle, close")
+				m.close(b)
+			default:
+				break loop
 			}
 		}
+		// the browsers are put back after the scan, so they aren't scanned twice
+		for _, b := range kept {
+			m.idle <- b
+		}
+		m.mutex.Unlock()
+	}
+}
+
+func (m *Manager) close(b *Browser) {
+	if b == nil {
+		return
+	}
+	if err := b.Close(context.Background()); err != nil {
+		m.logger.Error(err)
+	}
+}
+
+func (m *Manager) Close() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.done)
+
+	for len(m.idle) > 0 {
+		m.close(<-m.idle)
 	}
-	close(m.pool)
+	close(m.idle)
 }
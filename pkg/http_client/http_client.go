@@ -9,27 +9,36 @@ import (
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/dns_cache"
 	response2 "github.com/lizongying/go-crawler/pkg/response"
-	"github.com/lizongying/go-crawler/pkg/utils"
-	"github.com/lizongying/go-crawler/static"
 	utls "github.com/refraction-networking/utls"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
-	"strings"
+	"sync"
 	"time"
 )
 
 type HttpClient struct {
 	Ja3              bool
-	client           *http.Client
 	proxy            *url.URL
 	timeout          time.Duration
 	httpProto        string
 	logger           pkg.Logger
+	spider           pkg.Spider
 	redirectMaxTimes uint8
-	retryMaxTimes    uint8
+	maxBodySize      int64
 	dnsCache         *dns_cache.DnsCache
+	dialer           *net.Dialer
+	rootCAs          *x509.CertPool
+
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+	idleConnTimeout     time.Duration
+
+	// transports are shared across requests, so connections can be kept alive and multiplexed
+	transports      map[transportKey]*http.Transport
+	transportsMutex sync.Mutex
 }
 
 func NewClientJa3(ctx context.Context, conn net.Conn, cfg *tls.Config, helloID *utls.ClientHelloID, helloSpec *utls.ClientHelloSpec) (net.Conn, error) {
@@ -88,179 +97,55 @@ func (h *HttpClient) DoRequest(ctx context.Context, request pkg.Request) (respon
 		timeout = request.GetTimeout()
 	}
 
+	// the body of the files is streamed after returning, so the context is canceled when it's closed
+	streaming := false
 	if timeout > 0 {
 		c := context.Background()
 		c, cancel := context.WithTimeout(c, timeout)
-		defer cancel()
+		defer func() {
+			if !streaming {
+				cancel()
+			}
+		}()
+		defer func() {
+			if streaming {
+				response.GetResponse().Body = &cancelBody{ReadCloser: response.GetBody(), cancel: cancel}
+			}
+		}()
 		request.WithRequestContext(c)
 	}
 
-	// Get a copy of the default root CAs
-	defaultCAs, err := x509.SystemCertPool()
+	key, err := h.transportKey(request)
 	if err != nil {
-		defaultCAs = x509.NewCertPool()
-	}
-	defaultCAs.AppendCertsFromPEM(static.CaCert)
-
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   60 * time.Second,
-			KeepAlive: 60 * time.Second,
-			Resolver: func() *net.Resolver {
-				resolver := net.DefaultResolver
-				resolver.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
-					var host string
-					var port string
-					if host, port, err = net.SplitHostPort(address); err != nil {
-						return nil, err
-					}
-					var d net.Dialer
-					var c net.Conn
-					var e error
-					if ip, ok := h.dnsCache.Get(host); ok {
-						if strings.Contains(ip.String(), ".") {
-							c, e = d.DialContext(ctx, network, fmt.Sprintf("%s:%s", ip.String(), port))
-						} else {
-							c, e = d.DialContext(ctx, network, fmt.Sprintf("[%s]:%s", ip.String(), port))
-						}
-					} else {
-						c, e = d.DialContext(ctx, network, address)
-					}
-
-					h.logger.Info("RemoteAddr", c.RemoteAddr(), network, address)
-					return c, e
-				}
-				return resolver
-			}(),
-		}).DialContext,
-		DisableKeepAlives:     true,
-		IdleConnTimeout:       180 * time.Second,
-		TLSHandshakeTimeout:   20 * time.Second,
-		ExpectContinueTimeout: 2 * time.Second,
-		ResponseHeaderTimeout: 30 * time.Second,
-
-		MaxConnsPerHost:     1000,
-		MaxIdleConns:        1000,
-		MaxIdleConnsPerHost: 1000,
-
-		TLSClientConfig: &tls.Config{
-			RootCAs: defaultCAs,
-			//InsecureSkipVerify: true,
-		},
-	}
-	proxyEnable := false
-	if request.IsProxyEnable() != nil {
-		proxyEnable = *request.IsProxyEnable()
+		return
 	}
-	if proxyEnable {
-		proxy := h.proxy
-		if request.GetProxy() != nil {
-			proxy = request.GetProxy()
-		}
-		if proxy == nil {
-			err = errors.New("nil proxy")
-			return
-		}
-		transport.Proxy = http.ProxyURL(proxy)
+	transport, err := h.getTransport(key)
+	if err != nil {
+		h.logger.Error(err)
+		return
 	}
 
-	httpProto := h.httpProto
-	if request.GetHttpProto() != "" {
-		httpProto = request.GetHttpProto()
-	}
-	if httpProto != "2.0" {
-		request.GetHttpRequest().Proto = "HTTP/1.1"
-		request.GetHttpRequest().ProtoMajor = 1
-		request.GetHttpRequest().ProtoMinor = 1
-		transport.ForceAttemptHTTP2 = false
-	} else {
+	if key.http2 {
 		request.GetHttpRequest().Proto = "HTTP/2.0"
 		request.GetHttpRequest().ProtoMajor = 2
 		request.GetHttpRequest().ProtoMinor = 0
-		transport.ForceAttemptHTTP2 = true
-	}
-
-	if requiresHTTP1(request.GetHttpRequest()) {
-		transport.TLSClientConfig.NextProtos = nil
+	} else {
+		request.GetHttpRequest().Proto = "HTTP/1.1"
+		request.GetHttpRequest().ProtoMajor = 1
+		request.GetHttpRequest().ProtoMinor = 1
 	}
 
 	var resp *http.Response
-	if h.Ja3 {
-		transport.DialTLSContext = func(request pkg.Request) func(ctx context.Context, network, addr string) (net.Conn, error) {
-			return func(ctx context.Context, network, addr string) (net.Conn, error) {
-				var firstTLSHost string
-				var port string
-				if firstTLSHost, port, err = net.SplitHostPort(addr); err != nil {
-					h.logger.Error(err)
-					return nil, err
-				}
-
-				// Initiate TLS and check remote host name against certificate.
-				cfg := cloneTLSConfig(transport.TLSClientConfig)
-				if cfg.ServerName == "" {
-					cfg.ServerName = firstTLSHost
-				}
-				if request.GetHttpProto() == "2.0" {
-					cfg.NextProtos = []string{"h2", "http/1.1"}
-				} else {
-					cfg.NextProtos = []string{"http/1.1"}
-				}
-
-				var plainConn net.Conn
-				if ip, ok := h.dnsCache.Get(firstTLSHost); ok {
-					if strings.Contains(ip.String(), ".") {
-						plainConn, err = zeroDialer.DialContext(ctx, network, fmt.Sprintf("%s:%s", ip.String(), port))
-					} else {
-						plainConn, err = zeroDialer.DialContext(ctx, network, fmt.Sprintf("[%s]:%s", ip.String(), port))
-					}
-				} else {
-					plainConn, err = zeroDialer.DialContext(ctx, network, addr)
-					if err != nil {
-						h.logger.Error(err)
-						return nil, err
-					}
-					h.dnsCache.ResolveWithRetry(firstTLSHost)
-				}
-				if err != nil {
-					h.logger.Error(err)
-					return nil, err
-				}
-
-				var helloID *utls.ClientHelloID
-				var helloSpec *utls.ClientHelloSpec
-
-				switch pkg.Browser(request.GetFingerprint()) {
-				case pkg.BrowserChrome:
-					helloID = &utls.HelloChrome_Auto
-				case pkg.BrowserEdge:
-					helloID = &utls.HelloEdge_Auto
-				case pkg.BrowserSafari:
-					helloID = &utls.HelloSafari_Auto
-				case pkg.BrowserFireFox:
-					helloID = &utls.HelloFirefox_Auto
-				default:
-					if request.GetFingerprint() != "" {
-						helloSpec, err = stringToSpec(request.GetFingerprint())
-						if err != nil {
-							h.logger.Error(err)
-							helloID = &utls.HelloChrome_Auto
-						}
-					}
-				}
-
-				tlsConn, err := NewClientJa3(ctx, plainConn, cfg, helloID, helloSpec)
-				if err != nil {
-					h.logger.Error(err)
-					return nil, err
-				}
-
-				return tlsConn, nil
-			}
-		}(request)
+	client := &http.Client{
+		Transport: transport,
 	}
 
-	client := h.client
+	// the cookies are stored and sent by the jar, including the ones of the redirects
+	if h.spider != nil {
+		if jar := h.spider.GetCookieJar(request.GetCookieJar()); jar != nil {
+			client.Jar = jar
+		}
+	}
 
 	redirectMaxTimes := h.redirectMaxTimes
 	if request.GetRedirectMaxTimes() != nil {
@@ -275,9 +160,8 @@ func (h *HttpClient) DoRequest(ctx context.Context, request pkg.Request) (respon
 		}
 	}(redirectMaxTimes)
 
-	client.Transport = transport
-
-	if timeout > 0 {
+	// the body of the files is read after returning, it's limited by the context of the request instead
+	if timeout > 0 && !request.IsFile() {
 		client.Timeout = timeout
 	}
 	response = new(response2.Response).SetRequest(request)
@@ -291,17 +175,19 @@ func (h *HttpClient) DoRequest(ctx context.Context, request pkg.Request) (respon
 	response.SetResponse(resp)
 	response.SetSpendTime(time.Now().Sub(begin))
 
-	if err != nil {
-		retryMaxTimes := h.retryMaxTimes
-		if request.GetRetryMaxTimes() != nil {
-			retryMaxTimes = *request.GetRetryMaxTimes()
-		}
-		if request.GetRetryTimes() < retryMaxTimes {
+	if h.maxBodySize > 0 {
+		if resp.ContentLength > h.maxBodySize {
+			err = &pkg.BodyTooLargeError{MaxBodySize: h.maxBodySize}
+			h.logger.Error(err, request.GetUrl())
+			_ = resp.Body.Close()
 			return
 		}
-		h.logger.Error(err, "RetryTimes:", request.GetRetryTimes())
-		h.logger.Errorf("request: %+v", request)
-		h.logger.Debug(utils.Request2Curl(request))
+		resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: h.maxBodySize, maxBodySize: h.maxBodySize}
+	}
+
+	// the files are streamed to the store by the file middleware, instead of being read into memory
+	if request.IsFile() {
+		streaming = true
 		return
 	}
 
@@ -323,6 +209,12 @@ func (h *HttpClient) DoRequest(ctx context.Context, request pkg.Request) (respon
 	return
 }
 func (h *HttpClient) Close(_ context.Context) (err error) {
+	h.transportsMutex.Lock()
+	defer h.transportsMutex.Unlock()
+
+	for _, transport := range h.transports {
+		transport.CloseIdleConnections()
+	}
 	return
 }
 func (h *HttpClient) FromSpider(spider pkg.Spider) pkg.HttpClient {
@@ -330,18 +222,62 @@ func (h *HttpClient) FromSpider(spider pkg.Spider) pkg.HttpClient {
 		return new(HttpClient).FromSpider(spider)
 	}
 
-	config := spider.GetCrawler().GetConfig()
-	h.redirectMaxTimes = config.GetRedirectMaxTimes()
-
-	h.client = http.DefaultClient
+	h.spider = spider
+	h.init(spider.GetCrawler().GetConfig(), spider.GetLogger())
+	return h
+}
+func (h *HttpClient) init(config pkg.Config, logger pkg.Logger) {
 	h.proxy = config.GetProxy()
 	h.timeout = config.GetRequestTimeout()
 	h.httpProto = config.GetHttpProto()
-	h.logger = spider.GetLogger()
+	h.logger = logger
 	h.redirectMaxTimes = config.GetRedirectMaxTimes()
-	h.retryMaxTimes = config.GetRetryMaxTimes()
+	h.maxBodySize = config.GetRequestMaxBodySize()
 	h.Ja3 = config.GetEnableJa3()
 	h.dnsCache = dns_cache.NewDnsCache(time.Hour*24, 3)
+	h.dialer = &net.Dialer{
+		Timeout:   60 * time.Second,
+		KeepAlive: 60 * time.Second,
+	}
+	h.rootCAs = rootCAs()
+	h.maxIdleConns = config.GetRequestMaxIdleConns()
+	h.maxIdleConnsPerHost = config.GetRequestMaxIdleConnsPerHost()
+	h.maxConnsPerHost = config.GetRequestMaxConnsPerHost()
+	h.idleConnTimeout = config.GetRequestIdleConnTimeout()
+	h.transports = make(map[transportKey]*http.Transport)
+}
 
-	return h
+// limitedBody returns BodyTooLargeError if the body exceeds the max body size.
+type limitedBody struct {
+	io.ReadCloser
+	remaining   int64
+	maxBodySize int64
+}
+
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	if b.remaining < 0 {
+		return 0, &pkg.BodyTooLargeError{MaxBodySize: b.maxBodySize}
+	}
+	// read one more byte to find out if the body exceeds
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err = b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		n += int(b.remaining)
+		err = &pkg.BodyTooLargeError{MaxBodySize: b.maxBodySize}
+	}
+	return
+}
+
+// cancelBody cancels the context of the request when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
 }
@@ -0,0 +1,103 @@
+package http_client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/config"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	"github.com/lizongying/go-crawler/pkg/mock_servers"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMockServer(b *testing.B, logger pkg.Logger) *httptest.Server {
+	mux := http.NewServeMux()
+	route := mock_servers.NewRouteOk(logger)
+	mux.Handle(route.Pattern(), route)
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	b.Cleanup(srv.Close)
+	return srv
+}
+
+func newHttpClient(b *testing.B, cfg *config.Config, logger pkg.Logger, srv *httptest.Server) *HttpClient {
+	h := new(HttpClient)
+	h.init(cfg, logger)
+	h.rootCAs.AddCert(srv.Certificate())
+	b.Cleanup(func() {
+		_ = h.Close(context.Background())
+	})
+	return h
+}
+
+func doRequest(b *testing.B, h *HttpClient, url string) {
+	response, err := h.DoRequest(context.Background(), request.NewRequest().SetUrl(url))
+	if err != nil {
+		b.Fatal(err)
+	}
+	if response.StatusCode() != http.StatusOK {
+		b.Fatal(response.StatusCode())
+	}
+}
+
+// go test -bench=BenchmarkHttpClient_DoRequest -run=^$ ./pkg/http_client/
+func BenchmarkHttpClient_DoRequest(b *testing.B) {
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	srv := newMockServer(b, logger)
+	url := srv.URL + mock_servers.UrlOk
+
+	for _, httpProto := range []string{"1.1", "2.0"} {
+		cfg.Request.HttpProto = httpProto
+
+		// a new transport for every request, as before pooling
+		b.Run(fmt.Sprintf("unpooled-%s", httpProto), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				doRequest(b, newHttpClient(b, cfg, logger, srv), url)
+			}
+		})
+
+		b.Run(fmt.Sprintf("pooled-%s", httpProto), func(b *testing.B) {
+			h := newHttpClient(b, cfg, logger, srv)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				doRequest(b, h, url)
+			}
+		})
+
+		b.Run(fmt.Sprintf("pooled-parallel-%s", httpProto), func(b *testing.B) {
+			h := newHttpClient(b, cfg, logger, srv)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					doRequest(b, h, url)
+				}
+			})
+		})
+	}
+}
+
+func TestLimitedBody(t *testing.T) {
+	for body, exceeded := range map[string]bool{"12345": false, "123456": true} {
+		b := &limitedBody{ReadCloser: io.NopCloser(strings.NewReader(body)), remaining: 5, maxBodySize: 5}
+		bs, err := io.ReadAll(b)
+		if errors.Is(err, pkg.ErrBodyTooLarge) != exceeded {
+			t.Errorf("%s: got %v", body, err)
+		}
+		if len(bs) > 5 {
+			t.Errorf("%s: read %d bytes", body, len(bs))
+		}
+	}
+}
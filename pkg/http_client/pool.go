@@ -0,0 +1,216 @@
+package http_client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/static"
+	utls "github.com/refraction-networking/utls"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// transportKey identifies a pooled transport.
+// Requests with the same key share one transport and its idle connections.
+type transportKey struct {
+	proxy       string
+	http2       bool
+	ja3         bool
+	fingerprint string // only used when ja3 is enabled
+	http1Only   bool   // e.g. websocket upgrade, no ALPN
+}
+
+func (h *HttpClient) transportKey(request pkg.Request) (key transportKey, err error) {
+	proxyEnable := false
+	if request.IsProxyEnable() != nil {
+		proxyEnable = *request.IsProxyEnable()
+	}
+	if proxyEnable {
+		proxy := h.proxy
+		if request.GetProxy() != nil {
+			proxy = request.GetProxy()
+		}
+		if proxy == nil {
+			err = errors.New("nil proxy")
+			return
+		}
+		key.proxy = proxy.String()
+	}
+
+	httpProto := h.httpProto
+	if request.GetHttpProto() != "" {
+		httpProto = request.GetHttpProto()
+	}
+	key.http2 = httpProto == "2.0"
+	key.http1Only = requiresHTTP1(request.GetHttpRequest())
+
+	if h.Ja3 {
+		key.ja3 = true
+		key.fingerprint = request.GetFingerprint()
+	}
+	return
+}
+
+// getTransport returns the pooled transport for the key, creating it on first use.
+func (h *HttpClient) getTransport(key transportKey) (transport *http.Transport, err error) {
+	h.transportsMutex.Lock()
+	defer h.transportsMutex.Unlock()
+
+	transport, ok := h.transports[key]
+	if ok {
+		return
+	}
+
+	transport, err = h.newTransport(key)
+	if err != nil {
+		return
+	}
+	h.transports[key] = transport
+	return
+}
+
+func (h *HttpClient) newTransport(key transportKey) (transport *http.Transport, err error) {
+	transport = &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           h.dialContext,
+		IdleConnTimeout:       h.idleConnTimeout,
+		TLSHandshakeTimeout:   20 * time.Second,
+		ExpectContinueTimeout: 2 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+
+		MaxConnsPerHost:     h.maxConnsPerHost,
+		MaxIdleConns:        h.maxIdleConns,
+		MaxIdleConnsPerHost: h.maxIdleConnsPerHost,
+
+		ForceAttemptHTTP2: key.http2,
+
+		TLSClientConfig: &tls.Config{
+			RootCAs: h.rootCAs,
+			//InsecureSkipVerify: true,
+		},
+	}
+
+	if key.proxy != "" {
+		var proxy *url.URL
+		proxy, err = url.Parse(key.proxy)
+		if err != nil {
+			return
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if key.http1Only {
+		transport.TLSClientConfig.NextProtos = nil
+	}
+
+	if key.ja3 {
+		transport.DialTLSContext = h.dialTLSContext(key, transport.TLSClientConfig)
+	}
+
+	return
+}
+
+func (h *HttpClient) dialContext(ctx context.Context, network, addr string) (conn net.Conn, err error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+
+	ip, ok := h.dnsCache.Get(host)
+	if !ok {
+		return h.dialer.DialContext(ctx, network, addr)
+	}
+
+	return h.dialer.DialContext(ctx, network, joinHostPort(ip, port))
+}
+
+func (h *HttpClient) dialTLSContext(key transportKey, tlsConfig *tls.Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		firstTLSHost, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			h.logger.Error(err)
+			return nil, err
+		}
+
+		// Initiate TLS and check remote host name against certificate.
+		cfg := cloneTLSConfig(tlsConfig)
+		if cfg.ServerName == "" {
+			cfg.ServerName = firstTLSHost
+		}
+		if key.http2 {
+			cfg.NextProtos = []string{"h2", "http/1.1"}
+		} else {
+			cfg.NextProtos = []string{"http/1.1"}
+		}
+
+		var plainConn net.Conn
+		if ip, ok := h.dnsCache.Get(firstTLSHost); ok {
+			plainConn, err = zeroDialer.DialContext(ctx, network, joinHostPort(ip, port))
+		} else {
+			plainConn, err = zeroDialer.DialContext(ctx, network, addr)
+			if err != nil {
+				h.logger.Error(err)
+				return nil, err
+			}
+			h.dnsCache.ResolveWithRetry(firstTLSHost)
+		}
+		if err != nil {
+			h.logger.Error(err)
+			return nil, err
+		}
+
+		var helloID *utls.ClientHelloID
+		var helloSpec *utls.ClientHelloSpec
+
+		switch pkg.Browser(key.fingerprint) {
+		case pkg.BrowserChrome:
+			helloID = &utls.HelloChrome_Auto
+		case pkg.BrowserEdge:
+			helloID = &utls.HelloEdge_Auto
+		case pkg.BrowserSafari:
+			helloID = &utls.HelloSafari_Auto
+		case pkg.BrowserFireFox:
+			helloID = &utls.HelloFirefox_Auto
+		default:
+			if key.fingerprint != "" {
+				helloSpec, err = stringToSpec(key.fingerprint)
+				if err != nil {
+					h.logger.Error(err)
+					helloID = &utls.HelloChrome_Auto
+				}
+			}
+		}
+
+		tlsConn, err := NewClientJa3(ctx, plainConn, cfg, helloID, helloSpec)
+		if err != nil {
+			h.logger.Error(err)
+			_ = plainConn.Close()
+			return nil, err
+		}
+
+		return tlsConn, nil
+	}
+}
+
+func joinHostPort(ip net.IP, port string) string {
+	if strings.Contains(ip.String(), ".") {
+		return fmt.Sprintf("%s:%s", ip.String(), port)
+	}
+	return fmt.Sprintf("[%s]:%s", ip.String(), port)
+}
+
+// rootCAs returns a copy of the system root CAs with the bundled CA appended.
+func rootCAs() *x509.CertPool {
+	defaultCAs, err := x509.SystemCertPool()
+	if err != nil {
+		defaultCAs = x509.NewCertPool()
+	}
+	defaultCAs.AppendCertsFromPEM(static.CaCert)
+	return defaultCAs
+}
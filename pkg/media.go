@@ -15,6 +15,8 @@ type File interface {
 	SetName(string)
 	GetExt() string
 	SetExt(string)
+	GetSha256() string
+	SetSha256(string)
 }
 
 type ImageOptions struct {
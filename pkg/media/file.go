@@ -5,6 +5,7 @@ type File struct {
 	Url       string `json:"url,omitempty"`
 	Name      string `json:"name,omitempty"`
 	Ext       string `json:"ext,omitempty"`
+	Sha256    string `json:"sha256,omitempty"`
 }
 
 func (i *File) GetStorePath() string {
@@ -31,3 +32,9 @@ func (i *File) GetExt() string {
 func (i *File) SetExt(ext string) {
 	i.Ext = ext
 }
+func (i *File) GetSha256() string {
+	return i.Sha256
+}
+func (i *File) SetSha256(sha256 string) {
+	i.Sha256 = sha256
+}
@@ -1,6 +1,9 @@
 package pkg
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Middleware interface {
 	Start(context.Context, Spider) error
@@ -18,6 +21,21 @@ type Middleware interface {
 	FromSpider(Spider) Middleware
 }
 
+// MiddlewareWithResponse can respond to a request without downloading, e.g. the http cache middleware.
+// The downloader asks it after ProcessRequest, if a response is returned, the request isn't sent,
+// and the response is processed by ProcessResponse as usual.
+type MiddlewareWithResponse interface {
+	Middleware
+	Response(Context, Request) (Response, error)
+}
+
+// MiddlewareWithCrawlDelay tells the minimum delay between the requests of a slot, e.g. the crawl delay of robots.txt.
+// The auto throttle middleware doesn't go below it.
+type MiddlewareWithCrawlDelay interface {
+	Middleware
+	CrawlDelay(slot string) time.Duration
+}
+
 type UnimplementedMiddleware struct {
 	name    string
 	order   uint8
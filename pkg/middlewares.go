@@ -25,5 +25,8 @@ type Middlewares interface {
 	WithDecodeMiddleware()
 	WithDeviceMiddleware()
 	WithRecordErrorMiddleware()
+	WithHttpCacheMiddleware()
+	WithAutoThrottleMiddleware()
+	WithSessionMiddleware()
 	WithCustomMiddleware(Middleware)
 }
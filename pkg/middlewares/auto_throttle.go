@@ -0,0 +1,123 @@
+package middlewares
+
+import (
+	"context"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/throttle"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+// AutoThrottleMiddleware adjusts the rate and the burst of each request slot by the latency, 429/503 responses and timeouts.
+// The requests without slot are limited per host, unless request.slot is set.
+// The crawl delay of robots.txt is the minimum delay of the slot, and only one request starts at a time then.
+type AutoThrottleMiddleware struct {
+	pkg.UnimplementedMiddleware
+	logger            pkg.Logger
+	spider            pkg.Spider
+	targetConcurrency float64
+	minDelay          time.Duration
+	maxDelay          time.Duration
+	throttles         sync.Map
+	crawlDelays       []pkg.MiddlewareWithCrawlDelay
+}
+
+func (m *AutoThrottleMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
+	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	if spider.SlotType() == pkg.SlotTypeNone {
+		spider.SetSlotType(pkg.SlotTypeHost)
+	}
+	for _, v := range spider.GetMiddlewares().Middlewares() {
+		if crawlDelay, ok := v.(pkg.MiddlewareWithCrawlDelay); ok {
+			m.crawlDelays = append(m.crawlDelays, crawlDelay)
+		}
+	}
+	return
+}
+
+func (m *AutoThrottleMiddleware) ProcessResponse(c pkg.Context, response pkg.Response) (err error) {
+	request := response.GetRequest()
+	if request == nil {
+		return
+	}
+
+	timeout := false
+	statusCode := 0
+	if response.GetResponse() == nil {
+		// the other errors don't tell the load of the site
+		timeout = retryErrorClass(response.GetError()) == pkg.RetryErrorTimeout
+		if !timeout {
+			return
+		}
+	} else {
+		statusCode = response.StatusCode()
+	}
+
+	latency := request.GetSpendTime()
+	// the response isn't downloaded, e.g. it's cached
+	if latency == 0 && !timeout {
+		return
+	}
+
+	slot := request.GetSlot()
+	if slot == "" {
+		slot = "*"
+	}
+	value, ok := m.spider.RequestSlotLoad(slot)
+	if !ok {
+		return
+	}
+	limiter := value.(*rate.Limiter)
+
+	t, ok := m.throttles.Load(slot)
+	if !ok {
+		// start from the interval of the slot
+		delay := time.Duration(0)
+		if limit := limiter.Limit(); limit > 0 && limit != rate.Inf {
+			delay = time.Duration(float64(time.Second) / float64(limit))
+		}
+		t, _ = m.throttles.LoadOrStore(slot, throttle.NewThrottle(delay, m.minDelay, m.maxDelay, m.targetConcurrency))
+	}
+
+	th := t.(*throttle.Throttle)
+	var crawlDelay time.Duration
+	for _, v := range m.crawlDelays {
+		crawlDelay = max(crawlDelay, v.CrawlDelay(slot))
+	}
+	th.SetMinDelay(max(m.minDelay, crawlDelay))
+
+	delay := th.Observe(latency, statusCode, timeout)
+	limit := rate.Inf
+	if delay > 0 {
+		limit = rate.Every(delay)
+	}
+	// the requests which can start together after the slot is idle follow the concurrency of the slot
+	burst := th.Concurrency(latency)
+	if crawlDelay > 0 {
+		burst = 1
+	}
+	limiter.SetLimit(limit)
+	limiter.SetBurst(burst)
+
+	if stats, ok := c.GetTask().GetStats().(pkg.StatsWithThrottle); ok {
+		stats.SetThrottleDelay(slot, delay)
+	}
+	m.logger.Debugf("auto throttle slot: %s, latency: %v, status code: %d, delay: %v", slot, latency, statusCode, delay)
+	return
+}
+
+func (m *AutoThrottleMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
+	if m == nil {
+		return new(AutoThrottleMiddleware).FromSpider(spider)
+	}
+
+	m.UnimplementedMiddleware.FromSpider(spider)
+	m.logger = spider.GetLogger()
+	m.spider = spider
+	config := spider.GetConfig()
+	m.targetConcurrency = config.GetAutoThrottleTargetConcurrency()
+	m.minDelay = config.GetAutoThrottleMinDelay()
+	m.maxDelay = config.GetAutoThrottleMaxDelay()
+	return m
+}
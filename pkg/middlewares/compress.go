@@ -4,15 +4,26 @@ import (
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"compress/zlib"
 	"errors"
+	"fmt"
 	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 	"github.com/lizongying/go-crawler/pkg"
 	"io"
+	"strings"
 )
 
+// errEncodingNotSupported is returned by decompress for the unknown encodings, the body of which is kept as it is.
+var errEncodingNotSupported = errors.New("content encoding not supported")
+
+// minDecompressLimit is the decompressed size always allowed, as a small body can be compressed highly.
+const minDecompressLimit = 1 << 20
+
 type CompressMiddleware struct {
 	pkg.UnimplementedMiddleware
-	logger pkg.Logger
+	logger   pkg.Logger
+	maxRatio uint
 }
 
 func (m *CompressMiddleware) ProcessResponse(_ pkg.Context, response pkg.Response) (err error) {
@@ -21,66 +32,116 @@ func (m *CompressMiddleware) ProcessResponse(_ pkg.Context, response pkg.Respons
 		return
 	}
 
-	if response.GetHeader("Content-Encoding") == "deflate" {
-		reader := flate.NewReader(bytes.NewReader(response.BodyBytes()))
-		defer func() {
-			if reader != nil {
-				e := reader.Close()
-				if e != nil {
-					err = errors.Join(err, e)
-					m.logger.Error(err)
-				}
-			}
-		}()
+	// the body of the files is streamed
+	bodyBytes := response.BodyBytes()
+	if len(bodyBytes) == 0 {
+		return
+	}
 
-		var bodyBytes []byte
-		bodyBytes, err = io.ReadAll(reader)
+	encodings := contentEncodings(response.Headers().Values("Content-Encoding"))
+	if len(encodings) == 0 {
+		return
+	}
+
+	limit := int64(0)
+	if m.maxRatio > 0 {
+		limit = max(int64(len(bodyBytes))*int64(m.maxRatio), minDecompressLimit)
+	}
+
+	// the encodings are listed in the order they were applied
+	for i := len(encodings) - 1; i >= 0; i-- {
+		bodyBytes, err = decompress(encodings[i], bodyBytes, limit)
+		if errors.Is(err, errEncodingNotSupported) {
+			m.logger.Warn(err)
+			err = nil
+			return
+		}
 		if err != nil {
 			m.logger.Error(err)
 			return
 		}
-		response.SetBodyBytes(bodyBytes)
 	}
 
-	if response.GetHeader("Content-Encoding") == "gzip" {
-		reader, e := gzip.NewReader(bytes.NewReader(response.BodyBytes()))
-		if e != nil {
-			err = e
-			m.logger.Error(err)
+	response.SetBodyBytes(bodyBytes)
+	return
+}
+
+// contentEncodings returns the lower-cased encodings of the Content-Encoding headers, e.g. "gzip, br", identity is omitted.
+func contentEncodings(values []string) (encodings []string) {
+	for _, value := range values {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding == "" || encoding == "identity" {
+				continue
+			}
+			encodings = append(encodings, encoding)
+		}
+	}
+	return
+}
+
+// decompress decodes the body by the encoding, the decompressed body can't exceed the limit if it's greater than 0.
+func decompress(encoding string, body []byte, limit int64) (decompressed []byte, err error) {
+	var reader io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(body)); err != nil {
 			return
 		}
 		defer func() {
-			if reader != nil {
-				e := reader.Close()
-				if e != nil {
-					err = errors.Join(err, e)
-					m.logger.Error(err)
-				}
+			_ = r.Close()
+		}()
+		reader = r
+	case "deflate":
+		// deflate should be zlib-wrapped, but some servers send the raw deflate
+		var r io.ReadCloser
+		if isZlib(body) {
+			if r, err = zlib.NewReader(bytes.NewReader(body)); err != nil {
+				return
 			}
+		} else {
+			r = flate.NewReader(bytes.NewReader(body))
+		}
+		defer func() {
+			_ = r.Close()
 		}()
-
-		var bodyBytes []byte
-		bodyBytes, err = io.ReadAll(reader)
-		if err != nil {
-			m.logger.Error(err)
+		reader = r
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		var r *zstd.Decoder
+		if r, err = zstd.NewReader(bytes.NewReader(body)); err != nil {
 			return
 		}
-		response.SetBodyBytes(bodyBytes)
+		defer r.Close()
+		reader = r
+	default:
+		err = fmt.Errorf("%w: %s", errEncodingNotSupported, encoding)
+		return
 	}
 
-	if response.GetHeader("Content-Encoding") == "br" {
-		var bodyBytes []byte
-		bodyBytes, err = io.ReadAll(brotli.NewReader(bytes.NewReader(response.BodyBytes())))
-		if err != nil {
-			m.logger.Error(err)
-			return
-		}
-		response.SetBodyBytes(bodyBytes)
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	if decompressed, err = io.ReadAll(reader); err != nil {
+		return
+	}
+	if limit > 0 && int64(len(decompressed)) > limit {
+		err = fmt.Errorf("%w: %s exceeds %d bytes", pkg.ErrDecompressTooLarge, encoding, limit)
+		decompressed = nil
 	}
-
 	return
 }
 
+// isZlib reports whether the body starts with a zlib header of the deflate method.
+func isZlib(body []byte) bool {
+	if len(body) < 2 {
+		return false
+	}
+	return body[0]&0x0f == 8 && body[0]>>4 <= 7 && (uint16(body[0])<<8|uint16(body[1]))%31 == 0
+}
+
 func (m *CompressMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 	if m == nil {
 		return new(CompressMiddleware).FromSpider(spider)
@@ -88,5 +149,6 @@ func (m *CompressMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 
 	m.UnimplementedMiddleware.FromSpider(spider)
 	m.logger = spider.GetLogger()
+	m.maxRatio = spider.GetConfig().GetCompressMaxRatio()
 	return m
 }
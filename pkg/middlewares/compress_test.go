@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/lizongying/go-crawler/pkg"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func compress(t *testing.T, encoding string, body []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "deflate":
+		w, _ = flate.NewWriter(&buf, flate.BestCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		w, _ = zstd.NewWriter(&buf)
+	}
+	if _, err := w.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	body := []byte("Hello, Compress!")
+	for encoding, compressed := range map[string][]byte{
+		"gzip":          compress(t, "gzip", body),
+		"deflate":       compress(t, "zlib", body),
+		"deflate (raw)": compress(t, "deflate", body),
+		"br":            compress(t, "br", body),
+		"zstd":          compress(t, "zstd", body),
+	} {
+		name := encoding
+		if name == "deflate (raw)" {
+			name = "deflate"
+		}
+		decompressed, err := decompress(name, compressed, 0)
+		if err != nil {
+			t.Errorf("%s: %v", encoding, err)
+			continue
+		}
+		if !bytes.Equal(decompressed, body) {
+			t.Errorf("%s: got %q", encoding, decompressed)
+		}
+	}
+
+	if _, err := decompress("compress", body, 0); !errors.Is(err, errEncodingNotSupported) {
+		t.Errorf("unsupported encoding: got %v", err)
+	}
+
+	bomb := compress(t, "gzip", make([]byte, 10*minDecompressLimit))
+	if _, err := decompress("gzip", bomb, minDecompressLimit); !errors.Is(err, pkg.ErrDecompressTooLarge) {
+		t.Errorf("bomb: got %v", err)
+	}
+}
+
+func TestContentEncodings(t *testing.T) {
+	got := contentEncodings([]string{"GZIP, br", "identity", " Zstd "})
+	if want := []string{"gzip", "br", "zstd"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -1,33 +1,77 @@
 package middlewares
 
 import (
+	"context"
+	"fmt"
 	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/cookies"
+	"path/filepath"
 )
 
+// CookieMiddleware keeps the cookies in an RFC 6265 cookie jar per spider, or per jar name set by request.SetCookieJar.
+// The jars are used by the http client and the browser, and persisted by the cookie storage.
 type CookieMiddleware struct {
 	pkg.UnimplementedMiddleware
-	logger pkg.Logger
+	logger  pkg.Logger
+	config  pkg.Config
+	spider  pkg.Spider
+	storage cookies.Storage
 }
 
-func (m *CookieMiddleware) ProcessResponse(ctx pkg.Context, response pkg.Response) (err error) {
-	if response.GetResponse() == nil {
-		m.logger.Debug("response nil")
+func (m *CookieMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
+	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	if err != nil {
 		return
 	}
 
-	// add cookies to context
-	cookies := response.Cookies()
-	if len(cookies) > 0 {
-		request := ctx.GetRequest()
-		if request.GetCookies() == nil {
-			request.WithCookies(make(map[string]string))
+	switch m.config.GetCookieStorage() {
+	case pkg.CookieStorageFile:
+		m.storage, err = cookies.NewFileStorage(filepath.Join(m.config.GetCookieDir(), spider.Name()))
+	case pkg.CookieStorageRedis:
+		m.storage, err = cookies.NewRedisStorage(spider.GetCrawler().GetRedis(), fmt.Sprintf("%s:%s:cookies", m.config.GetBotName(), spider.Name()))
+	}
+	if err != nil {
+		m.logger.Error(err)
+		return
+	}
+
+	if m.storage != nil {
+		var jars map[string]pkg.CookieJar
+		jars, err = m.storage.Load()
+		if err != nil {
+			m.logger.Error(err)
+			return
 		}
-		for _, v := range cookies {
-			request.GetCookies()[v.Name] = v.Value
+		for name, jar := range jars {
+			spider.SetCookieJar(name, jar)
 		}
-		ctx.WithRequest(request)
+		m.logger.Info("cookie jars loaded", len(jars))
 	}
 
+	if spider.GetCookieJar(pkg.CookieJarDefault) == nil {
+		spider.SetCookieJar(pkg.CookieJarDefault, cookies.NewJar())
+	}
+	return
+}
+
+func (m *CookieMiddleware) ProcessRequest(_ pkg.Context, request pkg.Request) (err error) {
+	if m.spider.GetCookieJar(request.GetCookieJar()) == nil {
+		m.spider.LoadOrStoreCookieJar(request.GetCookieJar(), cookies.NewJar())
+	}
+	return
+}
+
+func (m *CookieMiddleware) Stop(_ pkg.Context) (err error) {
+	if m.storage == nil {
+		return
+	}
+
+	for name, jar := range m.spider.CookieJars() {
+		if err = m.storage.Save(name, jar); err != nil {
+			m.logger.Error(err)
+			return
+		}
+	}
 	return
 }
 
@@ -38,5 +82,7 @@ func (m *CookieMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 
 	m.UnimplementedMiddleware.FromSpider(spider)
 	m.logger = spider.GetLogger()
+	m.config = spider.GetConfig()
+	m.spider = spider
 	return m
 }
@@ -2,10 +2,7 @@ package middlewares
 
 import (
 	"github.com/lizongying/go-crawler/pkg"
-	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/simplifiedchinese"
-	"golang.org/x/text/encoding/traditionalchinese"
-	"strings"
+	"github.com/lizongying/go-crawler/pkg/charset"
 )
 
 type DecodeMiddleware struct {
@@ -19,32 +16,29 @@ func (m *DecodeMiddleware) ProcessResponse(_ pkg.Context, response pkg.Response)
 		return
 	}
 
-	contentType := strings.ToUpper(response.GetHeader("Content-Type"))
-
-	var decoder *encoding.Decoder
-	if strings.Contains(contentType, "=BIG5") {
-		decoder = traditionalchinese.Big5.NewDecoder()
-	}
-	if strings.Contains(contentType, "=GBK") {
-		decoder = simplifiedchinese.GBK.NewDecoder()
-	}
-	if strings.Contains(contentType, "=GB18030") {
-		decoder = simplifiedchinese.GB18030.NewDecoder()
+	bodyBytes := response.BodyBytes()
+	if len(bodyBytes) == 0 {
+		return
 	}
-	if strings.Contains(contentType, "=GB2312") {
-		decoder = simplifiedchinese.HZGB2312.NewDecoder()
+
+	contentType := response.GetHeader("Content-Type")
+	if !charset.IsText(contentType, bodyBytes) {
+		return
 	}
 
-	if decoder != nil {
-		var bodyBytes []byte
-		bodyBytes, err = decoder.Bytes(response.BodyBytes())
-		if err != nil {
-			m.logger.Error(err)
-			return
-		}
-		response.SetBodyBytes(bodyBytes)
+	bodyBytes, name, source, err := charset.Decode(bodyBytes, contentType)
+	if err != nil {
+		m.logger.Error(err)
+		return
+	}
+	if source == charset.SourceUnsupported {
+		m.logger.Warn("charset not supported:", name)
+	} else {
+		m.logger.Debug("charset:", name, "source:", source)
 	}
 
+	response.SetCharset(name)
+	response.SetBodyBytes(bodyBytes)
 	return
 }
 
@@ -13,6 +13,7 @@ import (
 type DeviceMiddleware struct {
 	pkg.UnimplementedMiddleware
 	logger    pkg.Logger
+	sessions  pkg.Sessions
 	uaAll     map[string][]device.Device
 	platforms []pkg.Platform
 	browsers  []pkg.Browser
@@ -20,6 +21,7 @@ type DeviceMiddleware struct {
 
 func (m *DeviceMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
 	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	m.sessions = spider.GetSessions()
 	if len(spider.GetPlatforms()) > 0 {
 		m.platforms = spider.GetPlatforms()
 	}
@@ -30,6 +32,13 @@ func (m *DeviceMiddleware) Start(ctx context.Context, spider pkg.Spider) (err er
 }
 
 func (m *DeviceMiddleware) ProcessRequest(_ pkg.Context, request pkg.Request) (err error) {
+	// the device is fixed by the session
+	if request.GetSession() != "" {
+		if session, ok := m.sessions.Get(request.GetSession()); ok && (session.UserAgent != "" || session.Fingerprint != "") {
+			return
+		}
+	}
+
 	platforms := m.platforms
 	if len(request.GetPlatforms()) > 0 {
 		platforms = request.GetPlatforms()
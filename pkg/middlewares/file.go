@@ -1,10 +1,22 @@
 package middlewares
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/media"
 	"github.com/lizongying/go-crawler/pkg/utils"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type FileMiddleware struct {
@@ -12,54 +24,192 @@ type FileMiddleware struct {
 	logger         pkg.Logger
 	store          pkg.Store
 	contentTypeMap map[string][]string
+	retryMaxTimes  uint8
+	retryDelay     time.Duration
+}
+
+func (m *FileMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
+	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	m.retryMaxTimes = spider.RetryMaxTimes()
+	return
+}
+
+// ProcessRequest resumes the partial file downloaded before by Range.
+func (m *FileMiddleware) ProcessRequest(_ pkg.Context, request pkg.Request) (err error) {
+	if !request.IsFile() {
+		return
+	}
+
+	store, ok := m.store.(pkg.StoreWithPartial)
+	if !ok {
+		return
+	}
+	size, ok := store.PartialSize("", utils.StrMd5(request.GetUrl()))
+	if !ok {
+		return
+	}
+
+	if size > 0 {
+		request.SetHeader("Range", fmt.Sprintf("bytes=%d-", size))
+	} else {
+		request.Headers().Del("Range")
+	}
+	return
 }
 
 func (m *FileMiddleware) ProcessResponse(c pkg.Context, response pkg.Response) (err error) {
-	if len(response.BodyBytes()) == 0 {
-		m.logger.Debug("BodyBytes empty")
+	if !response.IsFile() || response.GetResponse() == nil {
 		return
 	}
 
-	isFile := response.IsFile()
-	if isFile {
-		options := response.FileOptions()
-		i := new(media.File)
-		i.SetUrl(response.Url())
-		name := utils.StrMd5(response.Url())
-		if options.Name {
-			i.SetName(name)
+	name := utils.StrMd5(response.Url())
+	store, resumable := m.store.(pkg.StoreWithPartial)
+	if resumable {
+		_, resumable = store.PartialSize("", name)
+	}
+
+	switch response.StatusCode() {
+	case http.StatusOK, http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file doesn't match, it's emptied to download from the start on retry
+		if resumable {
+			m.truncatePartial(store, name)
 		}
-		ext := ""
-		if e, ok := m.contentTypeMap[response.GetHeader("Content-Type")]; ok {
-			ext = e[0]
-			if options.Ext {
-				i.SetExt(ext)
-			}
+		return
+	default:
+		return
+	}
+
+	options := response.FileOptions()
+	i := new(media.File)
+	i.SetUrl(response.Url())
+	if options.Name {
+		i.SetName(name)
+	}
+	ext := ""
+	if e, ok := m.contentTypeMap[response.GetHeader("Content-Type")]; ok {
+		ext = e[0]
+		if options.Ext {
+			i.SetExt(ext)
 		}
+	}
+
+	key := name
+	if ext != "" {
+		key = fmt.Sprintf("%s.%s", name, ext)
+	}
 
-		key := name
-		if ext != "" {
-			key = fmt.Sprintf("%s.%s", name, ext)
+	hash := sha256.New()
+	storePath := ""
+	if len(response.BodyBytes()) > 0 {
+		// the body is in memory if it's not downloaded by the http client, e.g. it's cached
+		storePath, err = m.store.SaveReader("", key, io.TeeReader(bytes.NewReader(response.BodyBytes()), hash))
+	} else if resumable {
+		storePath, err = m.saveResume(store, name, key, response, hash)
+	} else {
+		storePath, err = m.store.SaveReader("", key, io.TeeReader(response.GetBody(), hash))
+	}
+	if err != nil {
+		m.logger.Error(err)
+		request := response.GetRequest()
+		retryMaxTimes := m.retryMaxTimes
+		if request.GetRetryMaxTimes() != nil {
+			retryMaxTimes = *request.GetRetryMaxTimes()
 		}
-		storePath := ""
-		storePath, err = m.store.Save("", key, response.BodyBytes())
-		if err != nil {
-			m.logger.Error(err)
-			return
+		// the partial file is kept, so it's resumed by the retry
+		if resumable && !errors.Is(err, pkg.ErrBodyTooLarge) && request.GetRetryTimes() < retryMaxTimes {
+			request.SetRetryTimes(request.GetRetryTimes() + 1)
+			err = &pkg.RetryError{Delay: m.retryDelay}
 		}
-		i.SetStorePath(storePath)
+		return
+	}
+	i.SetStorePath(storePath)
+	i.SetSha256(hex.EncodeToString(hash.Sum(nil)))
 
-		response.SetFiles(append(response.Files(), i))
+	response.SetFiles(append(response.Files(), i))
 
-		stats, ok := c.GetTask().GetStats().(pkg.StatsWithFile)
-		if ok {
-			stats.IncFileTotal()
-		}
+	stats, ok := c.GetTask().GetStats().(pkg.StatsWithFile)
+	if ok {
+		stats.IncFileTotal()
+	}
+
+	return
+}
+
+// saveResume appends the body to the partial file, and moves it to the key when the body ends.
+// The partial file is kept if it fails, so the download can be resumed by Range.
+func (m *FileMiddleware) saveResume(store pkg.StoreWithPartial, name string, key string, response pkg.Response, h hash.Hash) (storePath string, err error) {
+	file, err := store.OpenPartial("", name)
+	if err != nil {
+		return
+	}
+
+	offset := int64(0)
+	if response.StatusCode() == http.StatusPartialContent {
+		offset, err = contentRangeStart(response.GetHeader("Content-Range"))
+	}
+	if err == nil {
+		err = m.appendPartial(file, offset, response.GetBody(), h)
+	}
+	if errors.Is(err, pkg.ErrBodyTooLarge) {
+		err = errors.Join(err, file.Truncate(0))
+	}
+	err = errors.Join(err, file.Close())
+	if err != nil {
+		return
+	}
+
+	return store.CommitPartial("", name, key)
+}
+
+func (m *FileMiddleware) appendPartial(file *os.File, offset int64, body io.Reader, h hash.Hash) (err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+	if info.Size() < offset {
+		err = errors.Join(fmt.Errorf("content range starts from %d, but the partial file is %d bytes", offset, info.Size()), file.Truncate(0))
+		return
+	}
+	// the rest of the partial file is replaced by the body
+	if err = file.Truncate(offset); err != nil {
+		return
 	}
 
+	// the checksum covers the partial file downloaded before
+	if _, err = io.Copy(h, io.LimitReader(file, offset)); err != nil {
+		return
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return
+	}
+	_, err = io.Copy(io.MultiWriter(file, h), body)
 	return
 }
 
+func (m *FileMiddleware) truncatePartial(store pkg.StoreWithPartial, name string) {
+	file, err := store.OpenPartial("", name)
+	if err != nil {
+		return
+	}
+	if err = errors.Join(file.Truncate(0), file.Close()); err != nil {
+		m.logger.Error(err)
+	}
+}
+
+// contentRangeStart returns the first byte position of the Content-Range, e.g. 100 of "bytes 100-999/1000".
+func contentRangeStart(contentRange string) (start int64, err error) {
+	r, ok := strings.CutPrefix(contentRange, "bytes ")
+	if ok {
+		r, _, ok = strings.Cut(r, "-")
+	}
+	if !ok {
+		err = fmt.Errorf("invalid content range: %s", contentRange)
+		return
+	}
+	return strconv.ParseInt(r, 10, 64)
+}
+
 func (m *FileMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 	if m == nil {
 		return new(FileMiddleware).FromSpider(spider)
@@ -69,6 +219,7 @@ func (m *FileMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 	crawler := spider.GetCrawler()
 	m.logger = spider.GetLogger()
 	m.store = crawler.GetStore()
+	m.retryDelay = spider.GetConfig().GetRetryDelay()
 
 	// https://developer.mozilla.org/zh-CN/docs/Web/Media/Formats/Image_types
 	// https://developer.mozilla.org/zh-CN/docs/Web/Media/Formats/Containers
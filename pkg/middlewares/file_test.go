@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentRangeStart(t *testing.T) {
+	if start, err := contentRangeStart("bytes 100-999/1000"); err != nil || start != 100 {
+		t.Errorf("got %d %v", start, err)
+	}
+	for _, v := range []string{"", "bytes */1000", "items 1-2/3"} {
+		if _, err := contentRangeStart(v); err == nil {
+			t.Errorf("%q should be invalid", v)
+		}
+	}
+}
+
+func TestAppendPartial(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "a.part"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// the partial file has a stale tail after the offset
+	if _, err = file.WriteString("hello, ###"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	h := sha256.New()
+	if err = new(FileMiddleware).appendPartial(file, 7, strings.NewReader("world"), h); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := os.ReadFile(file.Name())
+	if string(content) != "hello, world" {
+		t.Errorf("got %q", content)
+	}
+	if fmt.Sprintf("%x", h.Sum(nil)) != fmt.Sprintf("%x", sha256.Sum256(content)) {
+		t.Error("the checksum should cover the whole file")
+	}
+
+	if err = new(FileMiddleware).appendPartial(file, 100, strings.NewReader("world"), sha256.New()); err == nil {
+		t.Error("the offset beyond the partial file should be invalid")
+	}
+}
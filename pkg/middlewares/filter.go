@@ -3,12 +3,17 @@ package middlewares
 import (
 	"context"
 	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"github.com/lizongying/go-crawler/pkg/utils"
 )
 
 type FilterMiddleware struct {
 	pkg.UnimplementedMiddleware
-	logger pkg.Logger
-	filter pkg.Filter
+	logger        pkg.Logger
+	filter        pkg.Filter
+	fingerprint   bool
+	fingerprinter *request.Fingerprinter
+	okHttpCodes   []int
 }
 
 func (m *FilterMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
@@ -17,6 +22,23 @@ func (m *FilterMiddleware) Start(ctx context.Context, spider pkg.Spider) (err er
 	return
 }
 
+// uniqueKey returns the unique key of the request,
+// or the fingerprint if the unique key is not set and the fingerprint is enabled.
+func (m *FilterMiddleware) uniqueKey(request pkg.Request) (uniqueKey string, isFingerprint bool) {
+	uniqueKey = request.GetUniqueKey()
+	if uniqueKey != "" || !m.fingerprint {
+		return
+	}
+
+	// the http middleware is processed later, so the checksum may not be set yet
+	if request.GetChecksum() == "" {
+		request.SetChecksum(m.fingerprinter.Fingerprint(request))
+	}
+	uniqueKey = request.GetChecksum()
+	isFingerprint = true
+	return
+}
+
 func (m *FilterMiddleware) ProcessRequest(ctx pkg.Context, request pkg.Request) (err error) {
 	task := ctx.GetTask()
 	skipFilter := false
@@ -28,20 +50,21 @@ func (m *FilterMiddleware) ProcessRequest(ctx pkg.Context, request pkg.Request)
 		return
 	}
 
-	if request.GetUniqueKey() == "" {
+	uniqueKey, _ := m.uniqueKey(request)
+	if uniqueKey == "" {
 		m.logger.Debug("UniqueKey is empty")
 		return
 	}
 
-	ok, e := m.filter.IsExist(ctx, request.GetUniqueKey())
-	if err != nil {
+	ok, e := m.filter.IsExist(ctx, uniqueKey)
+	if e != nil {
 		err = e
 		return
 	}
 
 	if ok {
 		err = pkg.ErrIgnoreRequest
-		m.logger.Infof("%s in filter", request.GetUniqueKey())
+		m.logger.Infof("%s in filter", uniqueKey)
 		task.IncRequestIgnore()
 		return
 	}
@@ -49,6 +72,32 @@ func (m *FilterMiddleware) ProcessRequest(ctx pkg.Context, request pkg.Request)
 	return
 }
 
+// ProcessResponse stores the fingerprint after the request succeeds,
+// the unique key set by the spider is stored by the filter pipeline.
+func (m *FilterMiddleware) ProcessResponse(ctx pkg.Context, response pkg.Response) (err error) {
+	request := response.GetRequest()
+	if request == nil || response.GetResponse() == nil {
+		return
+	}
+	if request.IsSkipFilter() != nil && *request.IsSkipFilter() {
+		return
+	}
+	if !utils.InSlice(response.StatusCode(), m.okHttpCodes) {
+		return
+	}
+
+	uniqueKey, isFingerprint := m.uniqueKey(request)
+	if !isFingerprint || uniqueKey == "" {
+		return
+	}
+
+	err = m.filter.Store(ctx, uniqueKey)
+	if err != nil {
+		m.logger.Error(err)
+	}
+	return
+}
+
 func (m *FilterMiddleware) Stop(ctx pkg.Context) (err error) {
 	err = m.filter.Clean(ctx)
 	return
@@ -61,5 +110,9 @@ func (m *FilterMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 
 	m.UnimplementedMiddleware.FromSpider(spider)
 	m.logger = spider.GetLogger()
+	config := spider.GetConfig()
+	m.fingerprint = config.GetRequestFingerprintFilter()
+	m.fingerprinter = request.NewFingerprinter(config.GetRequestFingerprintIgnoreParams(), config.GetRequestFingerprintHeaders(), config.GetRequestFingerprintBody())
+	m.okHttpCodes = config.GetOkHttpCodes()
 	return m
 }
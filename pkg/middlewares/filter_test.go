@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"github.com/lizongying/go-crawler/pkg/config"
+	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
+	"github.com/lizongying/go-crawler/pkg/filters"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"github.com/lizongying/go-crawler/pkg/response"
+	"net/http"
+	"testing"
+)
+
+type filterExtra struct {
+	Count int
+}
+
+func TestFilterMiddleware_Extra(t *testing.T) {
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &FilterMiddleware{
+		logger:        logger,
+		filter:        new(filters.MemoryFilter),
+		fingerprint:   cfg.GetRequestFingerprintFilter(),
+		fingerprinter: request.NewFingerprinter(cfg.GetRequestFingerprintIgnoreParams(), cfg.GetRequestFingerprintHeaders(), cfg.GetRequestFingerprintBody()),
+		okHttpCodes:   cfg.GetOkHttpCodes(),
+	}
+	ctx := new(crawlerContext.Context)
+
+	// the same url is requested again with another extra, e.g. the next page of a counter
+	for i := 0; i < 2; i++ {
+		r := request.NewRequest().SetUrl("https://example.com/ok").SetExtra(&filterExtra{Count: i})
+		if err = m.ProcessRequest(ctx, r); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if err = m.ProcessResponse(ctx, new(response.Response).
+			SetRequest(r).
+			SetResponse(&http.Response{StatusCode: http.StatusOK})); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the fingerprint filter is opt-in, and the requests differing only in the extra are duplicates then
+	m.fingerprint = true
+	r := request.NewRequest().SetUrl("https://example.com/ok").SetExtra(&filterExtra{Count: 0})
+	if err = m.ProcessResponse(ctx, new(response.Response).
+		SetRequest(r).
+		SetResponse(&http.Response{StatusCode: http.StatusOK})); err != nil {
+		t.Fatal(err)
+	}
+	key, _ := m.uniqueKey(request.NewRequest().SetUrl("https://example.com/ok").SetExtra(&filterExtra{Count: 1}))
+	if ok, _ := m.filter.IsExist(ctx, key); !ok {
+		t.Error("the fingerprint should be stored")
+	}
+}
@@ -3,13 +3,15 @@ package middlewares
 import (
 	"errors"
 	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/request"
 	"github.com/lizongying/go-crawler/pkg/utils"
 	"net/http"
 )
 
 type HttpMiddleware struct {
 	pkg.UnimplementedMiddleware
-	logger pkg.Logger
+	logger        pkg.Logger
+	fingerprinter *request.Fingerprinter
 }
 
 func (m *HttpMiddleware) ProcessRequest(_ pkg.Context, request pkg.Request) (err error) {
@@ -28,7 +30,7 @@ func (m *HttpMiddleware) ProcessRequest(_ pkg.Context, request pkg.Request) (err
 		return
 	}
 	request.SetCreateTime(utils.NowStr())
-	request.SetChecksum(utils.StrMd5(request.GetMethod(), request.GetUrl(), request.GetBodyStr()))
+	request.SetChecksum(m.fingerprinter.Fingerprint(request))
 
 	canonicalHeaderKey := true
 	if request.IsCanonicalHeaderKey() != nil {
@@ -63,5 +65,7 @@ func (m *HttpMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 
 	m.UnimplementedMiddleware.FromSpider(spider)
 	m.logger = spider.GetLogger()
+	config := spider.GetConfig()
+	m.fingerprinter = request.NewFingerprinter(config.GetRequestFingerprintIgnoreParams(), config.GetRequestFingerprintHeaders(), config.GetRequestFingerprintBody())
 	return m
 }
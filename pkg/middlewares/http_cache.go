@@ -0,0 +1,206 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/http_cache"
+	"github.com/lizongying/go-crawler/pkg/request"
+	response2 "github.com/lizongying/go-crawler/pkg/response"
+	"github.com/lizongying/go-crawler/pkg/utils"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// httpCacheResponse is the response served from the cache, it isn't stored again.
+type httpCacheResponse struct {
+	*response2.Response
+}
+
+// HttpCacheMiddleware caches the responses by the request fingerprint, mainly for development.
+// The cached responses are served without downloading on subsequent runs.
+type HttpCacheMiddleware struct {
+	pkg.UnimplementedMiddleware
+	logger            pkg.Logger
+	config            pkg.Config
+	spider            pkg.Spider
+	storage           http_cache.Storage
+	policy            pkg.HttpCachePolicy
+	ttl               time.Duration
+	ignoreStatusCodes []int
+	fingerprinter     *request.Fingerprinter
+}
+
+func (m *HttpCacheMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
+	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	if err != nil {
+		return
+	}
+
+	switch m.config.GetHttpCacheStorage() {
+	case pkg.HttpCacheStorageSqlite:
+		sqlite := spider.GetCrawler().GetSqlite()
+		if sqlite == nil {
+			err = errors.New("sqlite nil")
+			m.logger.Error(err)
+			return
+		}
+		m.storage, err = http_cache.NewSqliteStorage(sqlite.Client(), fmt.Sprintf("%s_%s_http_cache", m.config.GetBotName(), spider.Name()))
+	default:
+		m.storage, err = http_cache.NewFileStorage(filepath.Join(m.config.GetHttpCacheDir(), spider.Name()))
+	}
+	if err != nil {
+		m.logger.Error(err)
+		return
+	}
+
+	m.logger.Info("http cache", m.config.GetHttpCacheStorage(), "policy", m.policy)
+	return
+}
+
+func (m *HttpCacheMiddleware) key(request pkg.Request) string {
+	if request.GetChecksum() == "" {
+		request.SetChecksum(m.fingerprinter.Fingerprint(request))
+	}
+	return request.GetChecksum()
+}
+
+// Response returns the cached response if it's usable, or adds the conditional headers to revalidate it.
+func (m *HttpCacheMiddleware) Response(_ pkg.Context, request pkg.Request) (response pkg.Response, err error) {
+	// the files are streamed, they aren't cached
+	if m.storage == nil || request.IsFile() {
+		return
+	}
+
+	entry, err := m.storage.Get(m.key(request))
+	if err != nil {
+		if !errors.Is(err, http_cache.ErrNotFound) {
+			m.logger.Error(err)
+		}
+		err = nil
+		return
+	}
+
+	now := time.Now()
+	if entry.Expired(m.ttl, now) {
+		return
+	}
+
+	if m.policy == pkg.HttpCachePolicyRfc9111 && !entry.Fresh(request.GetHttpRequest().Header, now) {
+		entry.SetValidators(request.GetHttpRequest())
+		return
+	}
+
+	response = &httpCacheResponse{Response: m.response(request, entry)}
+	m.logger.Debug("http cache hit", request.GetUrl())
+	return
+}
+
+func (m *HttpCacheMiddleware) response(request pkg.Request, entry *http_cache.Entry) *response2.Response {
+	response := new(response2.Response)
+	response.SetRequest(request).
+		SetResponse(&http.Response{
+			Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+			StatusCode:    entry.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Request:       request.GetHttpRequest(),
+		}).
+		SetBodyBytes(entry.Body)
+	return response
+}
+
+// ProcessResponse stores the downloaded response, or refreshes the cached one if it's not modified.
+func (m *HttpCacheMiddleware) ProcessResponse(_ pkg.Context, response pkg.Response) (err error) {
+	if m.storage == nil {
+		return
+	}
+
+	request := response.GetRequest()
+	if request == nil || request.IsFile() {
+		return
+	}
+
+	if _, ok := response.(*httpCacheResponse); ok {
+		return
+	}
+
+	if response.GetResponse() == nil {
+		return
+	}
+
+	now := time.Now()
+	if response.StatusCode() == http.StatusNotModified {
+		// the cached response revalidated by the validators added in Response
+		entry, e := m.storage.Get(m.key(request))
+		if e != nil {
+			if !errors.Is(e, http_cache.ErrNotFound) {
+				m.logger.Error(e)
+			}
+			return
+		}
+		entry.Revalidated(response.Headers(), now)
+		if err = m.storage.Set(m.key(request), entry); err != nil {
+			m.logger.Error(err)
+		}
+
+		cached := m.response(request, entry)
+		response.SetResponse(cached.GetResponse())
+		response.SetBodyBytes(cached.BodyBytes())
+		m.logger.Debug("http cache revalidated", request.GetUrl())
+		return
+	}
+
+	if utils.InSlice(response.StatusCode(), m.ignoreStatusCodes) {
+		return
+	}
+	if m.policy == pkg.HttpCachePolicyRfc9111 &&
+		!http_cache.Storable(request.GetMethod(), request.GetHttpRequest().Header, response.StatusCode(), response.Headers()) {
+		return
+	}
+
+	err = m.storage.Set(m.key(request), &http_cache.Entry{
+		Url:        request.GetUrl(),
+		StatusCode: response.StatusCode(),
+		Header:     response.Headers().Clone(),
+		Body:       response.BodyBytes(),
+		Time:       now,
+	})
+	if err != nil {
+		m.logger.Error(err)
+	}
+	return
+}
+
+func (m *HttpCacheMiddleware) Stop(_ pkg.Context) (err error) {
+	if m.storage == nil {
+		return
+	}
+
+	err = m.storage.Close()
+	return
+}
+
+func (m *HttpCacheMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
+	if m == nil {
+		return new(HttpCacheMiddleware).FromSpider(spider)
+	}
+
+	m.UnimplementedMiddleware.FromSpider(spider)
+	m.logger = spider.GetLogger()
+	m.config = spider.GetConfig()
+	m.spider = spider
+	m.policy = m.config.GetHttpCachePolicy()
+	m.ttl = m.config.GetHttpCacheTtl()
+	m.ignoreStatusCodes = m.config.GetHttpCacheIgnoreStatusCodes()
+	m.fingerprinter = request.NewFingerprinter(m.config.GetRequestFingerprintIgnoreParams(), m.config.GetRequestFingerprintHeaders(), m.config.GetRequestFingerprintBody())
+	return m
+}
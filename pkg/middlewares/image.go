@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/media"
@@ -63,6 +64,7 @@ func (m *ImageMiddleware) ProcessResponse(c pkg.Context, response pkg.Response)
 			return
 		}
 		i.SetStorePath(storePath)
+		i.SetSha256(fmt.Sprintf("%x", sha256.Sum256(response.BodyBytes())))
 
 		response.SetImages(append(response.Images(), i))
 		stats, ok := task.GetStats().(pkg.StatsWithImage)
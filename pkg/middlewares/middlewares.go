@@ -73,9 +73,19 @@ func (m *Middlewares) CleanMiddlewares() {
 func (m *Middlewares) WithCustomMiddleware(middleware pkg.Middleware) {
 	m.SetMiddleware(middleware, 10)
 }
+func (m *Middlewares) WithHttpCacheMiddleware() {
+	m.SetMiddleware(new(HttpCacheMiddleware), 15)
+}
 func (m *Middlewares) WithRetryMiddleware() {
 	m.SetMiddleware(new(RetryMiddleware), 20)
 }
+func (m *Middlewares) WithAutoThrottleMiddleware() {
+	m.SetMiddleware(new(AutoThrottleMiddleware), 25)
+}
+func (m *Middlewares) WithSessionMiddleware() {
+	m.SetMiddleware(new(SessionMiddleware), 35)
+	m.SetMiddleware(new(SessionCheckMiddleware), 165)
+}
 func (m *Middlewares) WithDumpMiddleware() {
 	m.SetMiddleware(new(DumpMiddleware), 30)
 }
@@ -141,9 +151,18 @@ func (m *Middlewares) FromSpider(spider pkg.Spider) pkg.Middlewares {
 	config := spider.GetCrawler().GetConfig()
 
 	// set middlewares
+	if config.GetEnableHttpCacheMiddleware() {
+		m.WithHttpCacheMiddleware()
+	}
+	if config.GetEnableAutoThrottleMiddleware() {
+		m.WithAutoThrottleMiddleware()
+	}
 	if config.GetEnableDumpMiddleware() {
 		m.WithDumpMiddleware()
 	}
+	if config.GetEnableSessionMiddleware() {
+		m.WithSessionMiddleware()
+	}
 	if config.GetEnableProxyMiddleware() {
 		m.WithProxyMiddleware()
 	}
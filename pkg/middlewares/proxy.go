@@ -1,16 +1,139 @@
 package middlewares
 
 import (
+	"context"
 	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/proxy"
+	"github.com/lizongying/go-crawler/pkg/utils"
+	"time"
 )
 
 type ProxyMiddleware struct {
 	pkg.UnimplementedMiddleware
-	logger pkg.Logger
+	logger         pkg.Logger
+	config         pkg.Config
+	crawler        pkg.Crawler
+	pool           *proxy.Pool
+	banStatusCodes []int
+}
+
+// loadProxies loads the proxy pool from the config list, file and redis set.
+func (m *ProxyMiddleware) loadProxies(ctx context.Context) (err error) {
+	urls := append([]string{}, m.config.GetProxyList()...)
+
+	if file := m.config.GetProxyFile(); file != "" {
+		var l []string
+		l, err = proxy.ReadFile(file)
+		if err != nil {
+			m.logger.Error(err)
+			return
+		}
+		urls = append(urls, l...)
+	}
+
+	if key := m.config.GetProxyRedisKey(); key != "" {
+		rds := m.crawler.GetRedis()
+		if rds == nil {
+			m.logger.Warn(`redis nil. please check if "redis_enable: false"`)
+		} else {
+			c, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			var l []string
+			l, err = rds.SMembers(c, key).Result()
+			if err != nil {
+				m.logger.Error(err)
+				return
+			}
+			urls = append(urls, l...)
+		}
+	}
+
+	if err = m.pool.Set(urls); err != nil {
+		m.logger.Error(err)
+		return
+	}
+
+	if m.pool.Len() > 0 {
+		m.logger.Info("proxies loaded:", m.pool.Len())
+	}
+	return
+}
+
+func (m *ProxyMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
+	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	if err != nil {
+		return
+	}
+
+	err = m.loadProxies(ctx)
+	return
 }
 
 func (m *ProxyMiddleware) ProcessRequest(_ pkg.Context, request pkg.Request) (err error) {
+	if m.pool.Len() == 0 {
+		return
+	}
+
+	if request.IsProxyEnable() != nil && !*request.IsProxyEnable() {
+		return
+	}
+
+	// the proxy is set by the spider
+	if request.GetProxy() != nil && !m.pool.Contains(request.GetProxy()) {
+		return
+	}
+
+	slot := request.GetSlot()
+	if slot == "" {
+		slot = "*"
+	}
+	p, err := m.pool.Get(slot)
+	if err != nil {
+		m.logger.Error(err)
+		return
+	}
+
+	request.SetProxyEnable(true)
+	request.SetProxy(p.String())
+	return
+}
 
+func (m *ProxyMiddleware) ProcessResponse(ctx pkg.Context, response pkg.Response) (err error) {
+	request := response.GetRequest()
+	if request == nil {
+		return
+	}
+
+	p := request.GetProxy()
+	if !m.pool.Contains(p) {
+		return
+	}
+
+	var stats pkg.StatsWithProxy
+	if task := ctx.GetTask(); task != nil {
+		stats, _ = task.GetStats().(pkg.StatsWithProxy)
+	}
+
+	// connection error or banned by the target
+	if response.GetResponse() == nil || utils.InSlice(response.StatusCode(), m.banStatusCodes) {
+		if response.GetResponse() == nil {
+			if m.pool.Failure(p) {
+				m.logger.Warn("proxy banned:", p.String())
+			}
+		} else {
+			m.pool.Ban(p)
+			m.logger.Warn("proxy banned:", p.String(), "status code:", response.StatusCode())
+		}
+		if stats != nil {
+			stats.IncProxyFailure(p.String())
+		}
+		return
+	}
+
+	m.pool.Success(p)
+	if stats != nil {
+		stats.IncProxySuccess(p.String())
+	}
 	return
 }
 
@@ -21,5 +144,9 @@ func (m *ProxyMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 
 	m.UnimplementedMiddleware.FromSpider(spider)
 	m.logger = spider.GetLogger()
+	m.crawler = spider.GetCrawler()
+	m.config = m.crawler.GetConfig()
+	m.pool = proxy.NewPool(m.config.GetProxyStrategy(), m.config.GetProxyMaxFailures(), m.config.GetProxyBanTime())
+	m.banStatusCodes = m.config.GetProxyBanStatusCodes()
 	return m
 }
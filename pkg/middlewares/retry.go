@@ -2,10 +2,19 @@ package middlewares
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/utils"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 type RetryMiddleware struct {
@@ -13,6 +22,11 @@ type RetryMiddleware struct {
 	logger        pkg.Logger
 	okHttpCodes   []int
 	retryMaxTimes uint8
+	backoff       pkg.RetryBackoff
+	delay         time.Duration
+	maxDelay      time.Duration
+	retryAfter    bool
+	retryErrors   []pkg.RetryErrorClass
 }
 
 func (m *RetryMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
@@ -36,21 +50,32 @@ func (m *RetryMiddleware) ProcessResponse(_ pkg.Context, response pkg.Response)
 	}
 
 	if response.GetResponse() == nil {
-		if request.GetRetryTimes() < retryMaxTimes {
+		class := retryErrorClass(response.GetError())
+		if request.GetRetryTimes() < retryMaxTimes && utils.InSlice(class, m.retryErrors) {
 			request.SetRetryTimes(request.GetRetryTimes() + 1)
-			m.logger.Infof("retry times: %d/%d, response nil, SpendTime: %v, UniqueKey: %s", request.GetRetryTimes(), retryMaxTimes, request.GetSpendTime(), request.GetUniqueKey())
-			err = pkg.ErrNeedRetry
+			delay := retryBackoff(m.backoff, m.delay, m.maxDelay, request.GetRetryTimes())
+			m.logger.Infof("retry times: %d/%d, response nil, error: %s, delay: %v, SpendTime: %v, UniqueKey: %s", request.GetRetryTimes(), retryMaxTimes, class, delay, request.GetSpendTime(), request.GetUniqueKey())
+			err = &pkg.RetryError{Delay: delay}
 			return
 		}
 		err = fmt.Errorf("response nil")
+		if response.GetError() != nil {
+			err = fmt.Errorf("response nil: %w", response.GetError())
+		}
 		return
 	}
 
 	if !utils.InSlice(response.StatusCode(), okHttpCodes) {
 		if request.GetRetryTimes() < retryMaxTimes {
 			request.SetRetryTimes(request.GetRetryTimes() + 1)
-			m.logger.Infof("retry times: %d/%d, status code: %d, SpendTime: %v, UniqueKey: %s", request.GetRetryTimes(), retryMaxTimes, response.StatusCode(), request.GetSpendTime(), request.GetUniqueKey())
-			err = pkg.ErrNeedRetry
+			delay := retryBackoff(m.backoff, m.delay, m.maxDelay, request.GetRetryTimes())
+			if m.retryAfter && (response.StatusCode() == http.StatusTooManyRequests || response.StatusCode() == http.StatusServiceUnavailable) {
+				if retryAfter, ok := parseRetryAfter(response.GetHeader("Retry-After"), time.Now(), m.maxDelay); ok {
+					delay = retryAfter
+				}
+			}
+			m.logger.Infof("retry times: %d/%d, status code: %d, delay: %v, SpendTime: %v, UniqueKey: %s", request.GetRetryTimes(), retryMaxTimes, response.StatusCode(), delay, request.GetSpendTime(), request.GetUniqueKey())
+			err = &pkg.RetryError{Delay: delay}
 			return
 		}
 
@@ -61,6 +86,89 @@ func (m *RetryMiddleware) ProcessResponse(_ pkg.Context, response pkg.Response)
 	return
 }
 
+// retryBackoff returns the delay before the retry, times starts from 1.
+func retryBackoff(backoff pkg.RetryBackoff, delay time.Duration, maxDelay time.Duration, times uint8) time.Duration {
+	if backoff != pkg.RetryBackoffFixed && times > 1 {
+		for i := uint8(1); i < times; i++ {
+			delay *= 2
+			if maxDelay > 0 && delay >= maxDelay {
+				break
+			}
+		}
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	if backoff == pkg.RetryBackoffJitter && delay > 0 {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+	return delay
+}
+
+// parseRetryAfter parses the Retry-After header, in seconds or an HTTP date.
+// The delay is capped by maxDelay if it's greater than 0, the same as the backoff.
+func parseRetryAfter(value string, now time.Time, maxDelay time.Duration) (delay time.Duration, ok bool) {
+	if value == "" {
+		return
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return
+		}
+		delay = time.Duration(seconds) * time.Second
+	} else {
+		t, err := http.ParseTime(value)
+		if err != nil {
+			return
+		}
+		delay = t.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+	}
+
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	ok = true
+	return
+}
+
+// retryErrorClass classifies the transport error, it's empty if the error can't be classified.
+func retryErrorClass(err error) pkg.RetryErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var dnsError *net.DNSError
+	var recordHeaderError tls.RecordHeaderError
+	var unknownAuthorityError x509.UnknownAuthorityError
+	var hostnameError x509.HostnameError
+	var certificateInvalidError x509.CertificateInvalidError
+	var netError net.Error
+	switch {
+	case errors.As(err, &dnsError):
+		return pkg.RetryErrorDns
+	case errors.As(err, &recordHeaderError),
+		errors.As(err, &unknownAuthorityError),
+		errors.As(err, &hostnameError),
+		errors.As(err, &certificateInvalidError):
+		return pkg.RetryErrorTls
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netError) && netError.Timeout():
+		return pkg.RetryErrorTimeout
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return pkg.RetryErrorReset
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return pkg.RetryErrorRefused
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return pkg.RetryErrorEof
+	default:
+		return ""
+	}
+}
+
 func (m *RetryMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 	if m == nil {
 		return new(RetryMiddleware).FromSpider(spider)
@@ -68,6 +176,12 @@ func (m *RetryMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 
 	m.UnimplementedMiddleware.FromSpider(spider)
 	m.logger = spider.GetLogger()
-	m.okHttpCodes = append(m.okHttpCodes, http.StatusMovedPermanently, http.StatusFound)
+	m.okHttpCodes = append(m.okHttpCodes, http.StatusPartialContent, http.StatusMovedPermanently, http.StatusFound)
+	config := spider.GetConfig()
+	m.backoff = config.GetRetryBackoff()
+	m.delay = config.GetRetryDelay()
+	m.maxDelay = config.GetRetryMaxDelay()
+	m.retryAfter = config.GetRetryAfter()
+	m.retryErrors = config.GetRetryErrors()
 	return m
 }
@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	for times, want := range map[uint8]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 10: time.Minute, 255: time.Minute} {
+		if delay := retryBackoff(pkg.RetryBackoffExponential, time.Second, time.Minute, times); delay != want {
+			t.Errorf("exponential %d: got %v, want %v", times, delay, want)
+		}
+	}
+
+	if delay := retryBackoff(pkg.RetryBackoffFixed, time.Second, time.Minute, 5); delay != time.Second {
+		t.Errorf("fixed: got %v", delay)
+	}
+
+	for i := 0; i < 100; i++ {
+		if delay := retryBackoff(pkg.RetryBackoffJitter, time.Second, time.Minute, 3); delay < 0 || delay > 4*time.Second {
+			t.Errorf("jitter: got %v", delay)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if delay, ok := parseRetryAfter("120", now, 0); !ok || delay != 2*time.Minute {
+		t.Errorf("seconds: got %v %v", delay, ok)
+	}
+	if delay, ok := parseRetryAfter("Mon, 01 Jan 2024 00:00:30 GMT", now, 0); !ok || delay != 30*time.Second {
+		t.Errorf("date: got %v %v", delay, ok)
+	}
+	if delay, ok := parseRetryAfter("86400", now, time.Minute); !ok || delay != time.Minute {
+		t.Errorf("capped seconds: got %v %v", delay, ok)
+	}
+	if delay, ok := parseRetryAfter("Tue, 02 Jan 2024 00:00:00 GMT", now, time.Minute); !ok || delay != time.Minute {
+		t.Errorf("capped date: got %v %v", delay, ok)
+	}
+	for _, v := range []string{"", "-1", "soon"} {
+		if _, ok := parseRetryAfter(v, now, 0); ok {
+			t.Errorf("%q should be invalid", v)
+		}
+	}
+}
+
+func TestRetryErrorClass(t *testing.T) {
+	for err, want := range map[error]pkg.RetryErrorClass{
+		&url.Error{Op: "Get", URL: "https://a.com", Err: &net.DNSError{Err: "no such host", Name: "a.com"}}:   pkg.RetryErrorDns,
+		&url.Error{Op: "Get", URL: "https://a.com", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}:   pkg.RetryErrorReset,
+		&url.Error{Op: "Get", URL: "https://a.com", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}: pkg.RetryErrorRefused,
+		fmt.Errorf("stopped after 1 redirects"): "",
+	} {
+		if class := retryErrorClass(err); class != want {
+			t.Errorf("%v: got %q, want %q", err, class, want)
+		}
+	}
+}
@@ -1,70 +1,167 @@
 package middlewares
 
 import (
-	"context"
 	"fmt"
 	"github.com/lizongying/go-crawler/pkg"
-	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
 	"github.com/lizongying/go-crawler/pkg/request"
 	"github.com/lizongying/go-crawler/pkg/utils"
 	"github.com/temoto/robotstxt"
-	"net/url"
+	"golang.org/x/time/rate"
+	"net/http"
+	"sync"
+	"time"
 )
 
+// robotsTxtTTL is how long a fetched robots.txt is cached, as suggested by RFC 9309.
+const robotsTxtTTL = 24 * time.Hour
+
+// robotsTxtErrorTTL is how long a failed fetch is cached, robots.txt is fetched again after it.
+const robotsTxtErrorTTL = 10 * time.Minute
+
+type robotsTxt struct {
+	once  sync.Once
+	group *robotstxt.Group
+	// disallowAll is set if the server is unavailable, as RFC 9309 suggests
+	disallowAll bool
+	fetchTime   time.Time
+	ttl         time.Duration
+}
+
 type RobotsTxtMiddleware struct {
 	pkg.UnimplementedMiddleware
 	spider    pkg.Spider
 	logger    pkg.Logger
 	userAgent string
-	group     *robotstxt.Group
 	ignoreUrl []string
+
+	// robotsTxt is keyed by scheme://host
+	robotsTxt      map[string]*robotsTxt
+	robotsTxtMutex sync.Mutex
+
+	// crawlDelays is keyed by slot
+	crawlDelays sync.Map
 }
 
-func (m *RobotsTxtMiddleware) SpiderStarted(c pkg.Context) (err error) {
-	if c.GetSpider().GetName() != m.spider.Name() {
-		return
+// CrawlDelay returns the crawl delay of robots.txt for the slot, 0 if there's none.
+func (m *RobotsTxtMiddleware) CrawlDelay(slot string) time.Duration {
+	if slot == "" {
+		slot = "*"
+	}
+	if value, ok := m.crawlDelays.Load(slot); ok {
+		return value.(time.Duration)
 	}
-	if c.GetSpider().GetStatus() != pkg.SpiderStatusRunning {
+	return 0
+}
+
+// getRobotsTxt returns the cached robots.txt of the host, it will be fetched on first use or after expiry.
+func (m *RobotsTxtMiddleware) getRobotsTxt(ctx pkg.Context, host string) *robotsTxt {
+	m.robotsTxtMutex.Lock()
+	r, ok := m.robotsTxt[host]
+	if !ok || (!r.fetchTime.IsZero() && time.Since(r.fetchTime) > r.ttl) {
+		r = new(robotsTxt)
+		m.robotsTxt[host] = r
+	}
+	m.robotsTxtMutex.Unlock()
+
+	r.once.Do(func() {
+		var ttl time.Duration
+		r.group, r.disallowAll, ttl = m.fetch(ctx, host)
+
+		m.robotsTxtMutex.Lock()
+		r.fetchTime = time.Now()
+		r.ttl = ttl
+		m.robotsTxtMutex.Unlock()
+	})
+	return r
+}
+
+// fetch gets the group of robots.txt for the user agent, nil means everything is allowed,
+// unless disallowAll is set for a server error. The failures are cached for robotsTxtErrorTTL only.
+func (m *RobotsTxtMiddleware) fetch(ctx pkg.Context, host string) (group *robotstxt.Group, disallowAll bool, ttl time.Duration) {
+	ttl = robotsTxtErrorTTL
+	response, err := m.spider.Request(ctx, request.NewRequest().
+		SetUrl(fmt.Sprintf("%s/robots.txt", host)).
+		SetSkipMiddleware(true))
+	if err != nil {
+		m.logger.Warn(host, err)
 		return
 	}
-	host := m.spider.GetHost()
-	if host == "" {
-		m.logger.Warn("host is emtpy")
+	if response == nil || response.GetResponse() == nil {
 		return
 	}
 
-	ctx := new(crawlerContext.Context)
-	r, e := m.spider.Request(ctx, request.NewRequest().SetUrl(fmt.Sprintf("%s/robots.txt", host)).SetSkipMiddleware(true))
-	if e != nil {
-		m.logger.Error(e)
+	if response.StatusCode() >= http.StatusInternalServerError {
+		m.logger.Warn(host, "robots.txt unavailable, disallow all", response.StatusCode())
+		disallowAll = true
 		return
 	}
-	robots, err := robotstxt.FromBytes(r.BodyBytes())
+
+	robots, err := robotstxt.FromStatusAndBytes(response.StatusCode(), response.BodyBytes())
 	if err != nil {
+		m.logger.Warn(host, err)
 		return
 	}
-	m.group = robots.FindGroup(m.userAgent)
+	ttl = robotsTxtTTL
+
+	if len(robots.Sitemaps) > 0 {
+		m.spider.AddSitemaps(robots.Sitemaps...)
+	}
+
+	group = robots.FindGroup(m.userAgent)
 	return
 }
 
-func (m *RobotsTxtMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
-	err = m.UnimplementedMiddleware.Start(ctx, spider)
-	spider.GetCrawler().GetSignal().RegisterSpiderChanged(m.SpiderStarted)
-	return
+// crawlDelay slows down the slot of the request if the robots.txt asks for a longer delay.
+func (m *RobotsTxtMiddleware) crawlDelay(request pkg.Request, delay time.Duration) {
+	slot := request.GetSlot()
+	if slot == "" {
+		slot = "*"
+	}
+	m.crawlDelays.Store(slot, delay)
+
+	value, ok := m.spider.RequestSlotLoad(slot)
+	if !ok {
+		m.spider.SetRequestRate(slot, delay, 1)
+		return
+	}
+
+	limiter := value.(*rate.Limiter)
+	if limiter.Limit() <= rate.Every(delay) {
+		return
+	}
+	limiter.SetBurst(1)
+	limiter.SetLimit(rate.Every(delay))
+	m.logger.Info("crawl delay", slot, delay)
 }
 
-func (m *RobotsTxtMiddleware) ProcessRequest(_ pkg.Context, request pkg.Request) (err error) {
-	if m.group == nil {
+func (m *RobotsTxtMiddleware) ProcessRequest(ctx pkg.Context, request pkg.Request) (err error) {
+	u := request.GetURL()
+	if u == nil || (u.Scheme != "http" && u.Scheme != "https") {
 		return
 	}
 
-	u, _ := url.Parse(request.GetUrl())
 	if utils.InSlice(u.Path, m.ignoreUrl) {
 		return
 	}
 
-	allow := m.group.Test(u.Path)
-	if !allow {
+	r := m.getRobotsTxt(ctx, fmt.Sprintf("%s://%s", u.Scheme, u.Host))
+	if r.disallowAll {
+		err = pkg.ErrNotAllowRequest
+		return
+	}
+	if r.group == nil {
+		return
+	}
+
+	if r.group.CrawlDelay > 0 {
+		m.crawlDelay(request, r.group.CrawlDelay)
+	}
+
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path = fmt.Sprintf("%s?%s", path, u.RawQuery)
+	}
+	if !r.group.Test(path) {
 		err = pkg.ErrNotAllowRequest
 		return
 	}
@@ -80,7 +177,8 @@ func (m *RobotsTxtMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
 	m.UnimplementedMiddleware.FromSpider(spider)
 	m.spider = spider
 	m.logger = spider.GetLogger()
-	m.userAgent = "*"
+	m.userAgent = spider.GetConfig().GetRobotsTxtUserAgent()
 	m.ignoreUrl = []string{"/robots.txt"}
+	m.robotsTxt = make(map[string]*robotsTxt)
 	return m
 }
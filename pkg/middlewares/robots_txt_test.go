@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/config"
+	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"github.com/lizongying/go-crawler/pkg/response"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type robotsTxtSpider struct {
+	pkg.Spider
+	logger pkg.Logger
+	config pkg.Config
+	// the status code of robots.txt, 0 for a network error
+	statusCode int
+	fetched    int
+}
+
+func (s *robotsTxtSpider) GetLogger() pkg.Logger { return s.logger }
+func (s *robotsTxtSpider) GetConfig() pkg.Config { return s.config }
+func (s *robotsTxtSpider) AddSitemaps(...string) {}
+func (s *robotsTxtSpider) Request(_ pkg.Context, r pkg.Request) (pkg.Response, error) {
+	s.fetched++
+	if s.statusCode == 0 {
+		return nil, errors.New("connection refused")
+	}
+	return new(response.Response).
+		SetRequest(r).
+		SetResponse(&http.Response{StatusCode: s.statusCode}).
+		SetBodyBytes([]byte("User-agent: *\nDisallow: /private\n")), nil
+}
+
+func TestRobotsTxtMiddleware_Failure(t *testing.T) {
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spider := &robotsTxtSpider{logger: logger, config: cfg, statusCode: http.StatusServiceUnavailable}
+	m := new(RobotsTxtMiddleware).FromSpider(spider).(*RobotsTxtMiddleware)
+	ctx := new(crawlerContext.Context)
+	process := func(url string) error {
+		return m.ProcessRequest(ctx, request.NewRequest().SetUrl(url))
+	}
+	// expire moves the fetch time of the cached robots.txt to the past
+	expire := func(d time.Duration) {
+		m.robotsTxt["https://example.com"].fetchTime = time.Now().Add(-d)
+	}
+
+	// the server is unavailable, everything is disallowed for a while
+	if err = process("https://example.com/a"); !errors.Is(err, pkg.ErrNotAllowRequest) {
+		t.Errorf("got %v, want %v", err, pkg.ErrNotAllowRequest)
+	}
+
+	// a network error allows everything for a while
+	spider.statusCode = 0
+	expire(robotsTxtErrorTTL + time.Second)
+	if err = process("https://example.com/private"); err != nil {
+		t.Errorf("got %v, want allowed", err)
+	}
+	if spider.fetched != 2 {
+		t.Errorf("fetched %d times, want 2", spider.fetched)
+	}
+
+	// robots.txt is fetched again after the failure, and cached for long
+	spider.statusCode = http.StatusOK
+	expire(robotsTxtErrorTTL + time.Second)
+	if err = process("https://example.com/private"); !errors.Is(err, pkg.ErrNotAllowRequest) {
+		t.Errorf("got %v, want %v", err, pkg.ErrNotAllowRequest)
+	}
+	expire(robotsTxtErrorTTL + time.Second)
+	if err = process("https://example.com/a"); err != nil {
+		t.Errorf("got %v, want allowed", err)
+	}
+	if spider.fetched != 3 {
+		t.Errorf("fetched %d times, want 3", spider.fetched)
+	}
+}
@@ -0,0 +1,138 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+)
+
+// SessionMiddleware binds the requests to the sessions of the spider, and logs in the sessions by the spider.
+// It's before the cookie, the proxy and the device middlewares, which use the attributes of the session.
+// The responses are checked by SessionCheckMiddleware.
+type SessionMiddleware struct {
+	pkg.UnimplementedMiddleware
+	logger   pkg.Logger
+	sessions pkg.Sessions
+	login    pkg.SpiderWithSessionLogin
+}
+
+func (m *SessionMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
+	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	if err != nil {
+		return
+	}
+
+	m.sessions = spider.GetSessions()
+	m.login, _ = spider.(pkg.SpiderWithSessionLogin)
+	m.logger.Info("sessions:", m.sessions.Len(), "login:", m.login != nil)
+	return
+}
+
+// ProcessRequest binds the request to a session which is logged in.
+// If the session can't be logged in, the next valid session is used.
+func (m *SessionMiddleware) ProcessRequest(ctx pkg.Context, request pkg.Request) (err error) {
+	if request.GetSession() == "" {
+		return
+	}
+
+	var login func(*pkg.Session) error
+	if m.login != nil {
+		login = func(session *pkg.Session) error {
+			m.logger.Info("session login:", session.Name)
+			return m.login.SessionLogin(ctx, session)
+		}
+	}
+
+	for i := 0; i <= m.sessions.Len(); i++ {
+		var session *pkg.Session
+		session, err = m.sessions.Bind(request)
+		if err != nil {
+			m.logger.Error(err)
+			return
+		}
+
+		if err = m.sessions.Login(session.Name, login); err == nil {
+			return
+		}
+		m.logger.Warn("session unavailable:", session.Name, err)
+		request.SetSession(pkg.SessionAny)
+	}
+
+	if err == nil {
+		err = pkg.ErrNoSession
+	}
+	return
+}
+
+func (m *SessionCheckMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
+	if m == nil {
+		return new(SessionCheckMiddleware).FromSpider(spider)
+	}
+
+	m.UnimplementedMiddleware.FromSpider(spider)
+	m.logger = spider.GetLogger()
+	return m
+}
+
+// SessionCheckMiddleware checks the responses of the requests bound to the sessions by the spider.
+// The request with an invalid session is retried after the session is logged in again, or with another session.
+// It's after the compress and the decode middlewares, so the check sees the decoded body.
+type SessionCheckMiddleware struct {
+	pkg.UnimplementedMiddleware
+	logger        pkg.Logger
+	sessions      pkg.Sessions
+	check         pkg.SpiderWithSessionCheck
+	retryMaxTimes uint8
+}
+
+func (m *SessionCheckMiddleware) Start(ctx context.Context, spider pkg.Spider) (err error) {
+	err = m.UnimplementedMiddleware.Start(ctx, spider)
+	if err != nil {
+		return
+	}
+
+	m.sessions = spider.GetSessions()
+	m.check, _ = spider.(pkg.SpiderWithSessionCheck)
+	m.retryMaxTimes = spider.RetryMaxTimes()
+	m.logger.Info("session check:", m.check != nil)
+	return
+}
+
+// ProcessResponse marks the session invalid if it's rejected by the check of the spider, and retries the request.
+func (m *SessionCheckMiddleware) ProcessResponse(ctx pkg.Context, response pkg.Response) (err error) {
+	request := response.GetRequest()
+	if m.check == nil || request == nil || request.GetSession() == "" || response.GetResponse() == nil {
+		return
+	}
+
+	if m.check.SessionCheck(ctx, response) {
+		return
+	}
+
+	name := request.GetSession()
+	m.sessions.Invalidate(name)
+
+	retryMaxTimes := m.retryMaxTimes
+	if request.GetRetryMaxTimes() != nil {
+		retryMaxTimes = *request.GetRetryMaxTimes()
+	}
+	if request.GetRetryTimes() >= retryMaxTimes {
+		err = fmt.Errorf("%w: %s", pkg.ErrSessionInvalid, name)
+		return
+	}
+
+	request.SetRetryTimes(request.GetRetryTimes() + 1)
+	m.logger.Infof("retry times: %d/%d, session invalid: %s, UniqueKey: %s", request.GetRetryTimes(), retryMaxTimes, name, request.GetUniqueKey())
+	err = &pkg.RetryError{}
+	return
+}
+
+func (m *SessionMiddleware) FromSpider(spider pkg.Spider) pkg.Middleware {
+	if m == nil {
+		return new(SessionMiddleware).FromSpider(spider)
+	}
+
+	m.UnimplementedMiddleware.FromSpider(spider)
+	m.logger = spider.GetLogger()
+	return m
+}
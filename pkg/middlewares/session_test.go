@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"context"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/config"
+	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"github.com/lizongying/go-crawler/pkg/response"
+	"github.com/lizongying/go-crawler/pkg/sessions"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type sessionSpider struct {
+	pkg.Spider
+	logger   pkg.Logger
+	config   pkg.Config
+	sessions pkg.Sessions
+	checked  string
+}
+
+func (s *sessionSpider) GetLogger() pkg.Logger     { return s.logger }
+func (s *sessionSpider) GetConfig() pkg.Config     { return s.config }
+func (s *sessionSpider) GetSessions() pkg.Sessions { return s.sessions }
+func (s *sessionSpider) RetryMaxTimes() uint8      { return 1 }
+
+// SessionCheck rejects the login page.
+func (s *sessionSpider) SessionCheck(_ pkg.Context, response pkg.Response) bool {
+	s.checked = response.BodyStr()
+	return !strings.Contains(s.checked, "login")
+}
+
+func TestSessionCheckMiddleware_Compressed(t *testing.T) {
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := sessions.NewPool()
+	pool.Add(&pkg.Session{Name: "a"})
+	spider := &sessionSpider{logger: logger, config: cfg, sessions: pool}
+
+	m := &Middlewares{spider: spider, logger: logger}
+	m.WithSessionMiddleware()
+	m.WithCompressMiddleware()
+	m.WithDecodeMiddleware()
+	for _, v := range m.Middlewares() {
+		if err = v.Start(context.Background(), spider); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, body := range []string{"<html><body>welcome</body></html>", "<html><body>please login</body></html>"} {
+		r := request.NewRequest().SetUrl("https://example.com/").SetSession("a")
+		res := new(response.Response).
+			SetRequest(r).
+			SetResponse(&http.Response{
+				StatusCode: http.StatusOK,
+				Header: http.Header{
+					"Content-Encoding": []string{"gzip"},
+					"Content-Type":     []string{"text/html; charset=utf-8"},
+				},
+			}).
+			SetBodyBytes(compress(t, "gzip", []byte(body)))
+
+		// the middlewares process the response in order, as the downloader does
+		err = nil
+		for _, v := range m.Middlewares() {
+			if e := v.ProcessResponse(new(crawlerContext.Context), res); e != nil {
+				err = e
+			}
+		}
+		if spider.checked != body {
+			t.Errorf("the check got %q, want the decompressed body", spider.checked)
+		}
+		if _, retry := pkg.RetryDelay(err); retry != strings.Contains(body, "login") {
+			t.Errorf("%q: got %v", body, err)
+		}
+	}
+}
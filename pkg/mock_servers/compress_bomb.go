@@ -0,0 +1,43 @@
+package mock_servers
+
+import (
+	"compress/gzip"
+	"github.com/lizongying/go-crawler/pkg"
+	"net/http"
+)
+
+const UrlCompressBomb = "/compress-bomb"
+
+// RouteCompressBomb sends a small gzip body which is decompressed to 100MB of zeros.
+type RouteCompressBomb struct {
+	logger pkg.Logger
+}
+
+func (h *RouteCompressBomb) Pattern() string {
+	return UrlCompressBomb
+}
+
+func (h *RouteCompressBomb) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerCompressBomb")
+	defer func() {
+		h.logger.Info("exit HandlerCompressBomb")
+	}()
+
+	w.Header().Set("Content-Encoding", "gzip")
+
+	gw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+	defer func() {
+		_ = gw.Close()
+	}()
+
+	zeros := make([]byte, 1<<20)
+	for i := 0; i < 100; i++ {
+		if _, err := gw.Write(zeros); err != nil {
+			return
+		}
+	}
+}
+
+func NewRouteCompressBomb(logger pkg.Logger) pkg.Route {
+	return &RouteCompressBomb{logger: logger}
+}
@@ -0,0 +1,38 @@
+package mock_servers
+
+import (
+	"compress/zlib"
+	"github.com/lizongying/go-crawler/pkg"
+	"net/http"
+)
+
+const UrlDeflateZlib = "/deflate-zlib"
+
+// RouteDeflateZlib sends the zlib-wrapped deflate as RFC 9110, RouteDeflate sends the raw deflate.
+type RouteDeflateZlib struct {
+	logger pkg.Logger
+}
+
+func (h *RouteDeflateZlib) Pattern() string {
+	return UrlDeflateZlib
+}
+
+func (h *RouteDeflateZlib) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerDeflateZlib")
+	defer func() {
+		h.logger.Info("exit HandlerDeflateZlib")
+	}()
+
+	w.Header().Set("Content-Encoding", "deflate")
+
+	fw := zlib.NewWriter(w)
+	defer func() {
+		_ = fw.Close()
+	}()
+
+	_, _ = fw.Write([]byte("Hello, Deflate zlib!"))
+}
+
+func NewRouteDeflateZlib(logger pkg.Logger) pkg.Route {
+	return &RouteDeflateZlib{logger: logger}
+}
@@ -0,0 +1,39 @@
+package mock_servers
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"golang.org/x/text/encoding/korean"
+	"net/http"
+)
+
+const UrlEucKr = "/euc-kr"
+
+type RouteEucKr struct {
+	logger pkg.Logger
+}
+
+func (h *RouteEucKr) Pattern() string {
+	return UrlEucKr
+}
+
+func (h *RouteEucKr) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerEucKr")
+	defer func() {
+		h.logger.Info("exit HandlerEucKr")
+	}()
+
+	encoder := korean.EUCKR.NewEncoder()
+	eucKrBytes, err := encoder.Bytes([]byte(`<html><head><meta charset="euc-kr"></head><body>한국어 텍스트EUC-KR</body></html>`))
+	if err != nil {
+		h.logger.Error(err)
+	}
+
+	// the charset is declared by the meta tag only
+	w.Header().Set("Content-Type", "text/html")
+
+	_, _ = w.Write(eucKrBytes)
+}
+
+func NewRouteEucKr(logger pkg.Logger) pkg.Route {
+	return &RouteEucKr{logger: logger}
+}
@@ -22,7 +22,8 @@ func (h *RouteGb2312) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
 		h.logger.Info("exit HandlerGb2312")
 	}()
 
-	encoder := simplifiedchinese.HZGB2312.NewEncoder()
+	// gb2312 is labeled for gbk by browsers
+	encoder := simplifiedchinese.GBK.NewEncoder()
 	gb2312Bytes, _ := encoder.Bytes([]byte("汉字GB2312"))
 
 	w.Header().Set("Content-Type", "text/plain; charset=GB2312")
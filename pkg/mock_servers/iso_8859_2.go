@@ -0,0 +1,39 @@
+package mock_servers
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"golang.org/x/text/encoding/charmap"
+	"net/http"
+)
+
+const UrlIso88592 = "/iso-8859-2"
+
+type RouteIso88592 struct {
+	logger pkg.Logger
+}
+
+func (h *RouteIso88592) Pattern() string {
+	return UrlIso88592
+}
+
+func (h *RouteIso88592) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerIso88592")
+	defer func() {
+		h.logger.Info("exit HandlerIso88592")
+	}()
+
+	encoder := charmap.ISO8859_2.NewEncoder()
+	iso88592Bytes, err := encoder.Bytes([]byte(`<?xml version="1.0" encoding="ISO-8859-2"?><text>Zażółć gęślą jaźń ISO-8859-2</text>`))
+	if err != nil {
+		h.logger.Error(err)
+	}
+
+	// the charset is declared by the xml declaration only
+	w.Header().Set("Content-Type", "application/xml")
+
+	_, _ = w.Write(iso88592Bytes)
+}
+
+func NewRouteIso88592(logger pkg.Logger) pkg.Route {
+	return &RouteIso88592{logger: logger}
+}
@@ -0,0 +1,41 @@
+package mock_servers
+
+import (
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"github.com/lizongying/go-crawler/pkg"
+	"net/http"
+)
+
+const UrlMultiCompress = "/multi-compress"
+
+// RouteMultiCompress compresses by gzip and then brotli.
+type RouteMultiCompress struct {
+	logger pkg.Logger
+}
+
+func (h *RouteMultiCompress) Pattern() string {
+	return UrlMultiCompress
+}
+
+func (h *RouteMultiCompress) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerMultiCompress")
+	defer func() {
+		h.logger.Info("exit HandlerMultiCompress")
+	}()
+
+	w.Header().Set("Content-Encoding", "GZIP, br")
+
+	bw := brotli.NewWriter(w)
+	gw := gzip.NewWriter(bw)
+	defer func() {
+		_ = gw.Close()
+		_ = bw.Close()
+	}()
+
+	_, _ = gw.Write([]byte("Hello, Gzip and Brotli!"))
+}
+
+func NewRouteMultiCompress(logger pkg.Logger) pkg.Route {
+	return &RouteMultiCompress{logger: logger}
+}
@@ -0,0 +1,38 @@
+package mock_servers
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"golang.org/x/text/encoding/japanese"
+	"net/http"
+)
+
+const UrlShiftJis = "/shift-jis"
+
+type RouteShiftJis struct {
+	logger pkg.Logger
+}
+
+func (h *RouteShiftJis) Pattern() string {
+	return UrlShiftJis
+}
+
+func (h *RouteShiftJis) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerShiftJis")
+	defer func() {
+		h.logger.Info("exit HandlerShiftJis")
+	}()
+
+	encoder := japanese.ShiftJIS.NewEncoder()
+	shiftJisBytes, err := encoder.Bytes([]byte("日本語のテキストShift_JIS"))
+	if err != nil {
+		h.logger.Error(err)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=Shift_JIS")
+
+	_, _ = w.Write(shiftJisBytes)
+}
+
+func NewRouteShiftJis(logger pkg.Logger) pkg.Route {
+	return &RouteShiftJis{logger: logger}
+}
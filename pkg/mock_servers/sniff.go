@@ -0,0 +1,39 @@
+package mock_servers
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"net/http"
+)
+
+const UrlSniff = "/sniff"
+
+type RouteSniff struct {
+	logger pkg.Logger
+}
+
+func (h *RouteSniff) Pattern() string {
+	return UrlSniff
+}
+
+func (h *RouteSniff) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerSniff")
+	defer func() {
+		h.logger.Info("exit HandlerSniff")
+	}()
+
+	encoder := simplifiedchinese.GBK.NewEncoder()
+	gbkBytes, err := encoder.Bytes([]byte("这是一段没有声明编码的中文文本，编码需要通过内容来检测。"))
+	if err != nil {
+		h.logger.Error(err)
+	}
+
+	// the charset isn't declared
+	w.Header().Set("Content-Type", "text/plain")
+
+	_, _ = w.Write(gbkBytes)
+}
+
+func NewRouteSniff(logger pkg.Logger) pkg.Route {
+	return &RouteSniff{logger: logger}
+}
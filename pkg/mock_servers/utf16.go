@@ -0,0 +1,39 @@
+package mock_servers
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"golang.org/x/text/encoding/unicode"
+	"net/http"
+)
+
+const UrlUtf16 = "/utf-16"
+
+type RouteUtf16 struct {
+	logger pkg.Logger
+}
+
+func (h *RouteUtf16) Pattern() string {
+	return UrlUtf16
+}
+
+func (h *RouteUtf16) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerUtf16")
+	defer func() {
+		h.logger.Info("exit HandlerUtf16")
+	}()
+
+	// the encoder writes the BOM
+	encoder := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder()
+	utf16Bytes, err := encoder.Bytes([]byte("UTF-16文本"))
+	if err != nil {
+		h.logger.Error(err)
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+
+	_, _ = w.Write(utf16Bytes)
+}
+
+func NewRouteUtf16(logger pkg.Logger) pkg.Route {
+	return &RouteUtf16{logger: logger}
+}
@@ -0,0 +1,39 @@
+package mock_servers
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"golang.org/x/text/encoding/charmap"
+	"net/http"
+)
+
+const UrlWindows1251 = "/windows-1251"
+
+type RouteWindows1251 struct {
+	logger pkg.Logger
+}
+
+func (h *RouteWindows1251) Pattern() string {
+	return UrlWindows1251
+}
+
+func (h *RouteWindows1251) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerWindows1251")
+	defer func() {
+		h.logger.Info("exit HandlerWindows1251")
+	}()
+
+	encoder := charmap.Windows1251.NewEncoder()
+	windows1251Bytes, err := encoder.Bytes([]byte(`<html><head><meta http-equiv="Content-Type" content="text/html; charset=windows-1251"></head><body>Русский текст Windows-1251</body></html>`))
+	if err != nil {
+		h.logger.Error(err)
+	}
+
+	// the charset is declared by the http-equiv meta tag only
+	w.Header().Set("Content-Type", "text/html")
+
+	_, _ = w.Write(windows1251Bytes)
+}
+
+func NewRouteWindows1251(logger pkg.Logger) pkg.Route {
+	return &RouteWindows1251{logger: logger}
+}
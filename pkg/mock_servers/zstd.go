@@ -0,0 +1,37 @@
+package mock_servers
+
+import (
+	"github.com/klauspost/compress/zstd"
+	"github.com/lizongying/go-crawler/pkg"
+	"net/http"
+)
+
+const UrlZstd = "/zstd"
+
+type RouteZstd struct {
+	logger pkg.Logger
+}
+
+func (h *RouteZstd) Pattern() string {
+	return UrlZstd
+}
+
+func (h *RouteZstd) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	h.logger.Info("into HandlerZstd")
+	defer func() {
+		h.logger.Info("exit HandlerZstd")
+	}()
+
+	w.Header().Set("Content-Encoding", "zstd")
+
+	fw, _ := zstd.NewWriter(w)
+	defer func() {
+		_ = fw.Close()
+	}()
+
+	_, _ = fw.Write([]byte("Hello, Zstd!"))
+}
+
+func NewRouteZstd(logger pkg.Logger) pkg.Route {
+	return &RouteZstd{logger: logger}
+}
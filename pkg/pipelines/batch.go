@@ -0,0 +1,141 @@
+package pipelines
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"sync"
+	"time"
+)
+
+// batch is the buffered items of a key, e.g. a table.
+type batch struct {
+	items []pkg.Item
+	timer *time.Timer
+}
+
+// itemErrors is returned by write if only some items failed, the other items are written.
+type itemErrors map[pkg.Item]error
+
+func (e itemErrors) Error() string {
+	return fmt.Sprintf("%d items failed", len(e))
+}
+
+// batcher buffers the items per key, and writes a batch once it's full or the interval has passed.
+// The buffered items hold their task, so the task doesn't stop before they're written.
+type batcher struct {
+	size     int
+	interval time.Duration
+	write    func(key string, items []pkg.Item) (skipped []pkg.Item, err error)
+
+	crawler pkg.Crawler
+	logger  pkg.Logger
+
+	mutex   sync.Mutex
+	batches map[string]*batch
+}
+
+// newBatcher returns the batcher writing the batches by write,
+// which returns the items skipped by the store, e.g. the duplicates, and itemErrors if only some items failed.
+func newBatcher(spider pkg.Spider, write func(key string, items []pkg.Item) (skipped []pkg.Item, err error)) *batcher {
+	config := spider.GetConfig()
+	return &batcher{
+		size:     config.GetPipelineBatchSize(),
+		interval: config.GetPipelineBatchInterval(),
+		write:    write,
+		crawler:  spider.GetCrawler(),
+		logger:   spider.GetLogger(),
+		batches:  make(map[string]*batch),
+	}
+}
+
+// add buffers the item. If the batch becomes full, it's written at once,
+// and the error of the batch is returned for the item.
+func (b *batcher) add(key string, item pkg.Item) (err error) {
+	item.GetContext().GetTask().ItemIn()
+	if b.size <= 1 {
+		return b.flush(key, []pkg.Item{item}, item)
+	}
+
+	b.mutex.Lock()
+	bt, ok := b.batches[key]
+	if !ok {
+		bt = new(batch)
+		b.batches[key] = bt
+		bt.timer = time.AfterFunc(b.interval, func() {
+			if items := b.take(key, bt); len(items) > 0 {
+				_ = b.flush(key, items, nil)
+			}
+		})
+	}
+	bt.items = append(bt.items, item)
+	full := len(bt.items) >= b.size
+	b.mutex.Unlock()
+
+	if !full {
+		return
+	}
+
+	if items := b.take(key, bt); len(items) > 0 {
+		err = b.flush(key, items, item)
+	}
+	return
+}
+
+// take detaches the batch, if it's still the current batch of the key.
+func (b *batcher) take(key string, bt *batch) (items []pkg.Item) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.batches[key] != bt {
+		return
+	}
+	delete(b.batches, key)
+	bt.timer.Stop()
+	return bt.items
+}
+
+// flush writes the items, and reports the result to the items except the current one,
+// whose result is returned to the exporter.
+func (b *batcher) flush(key string, items []pkg.Item, current pkg.Item) (err error) {
+	skipped, err := b.write(key, items)
+	var failed itemErrors
+	if errors.As(err, &failed) {
+		err = nil
+	}
+	if err != nil {
+		b.logger.Error(key, "write", len(items), "items failed", err)
+	} else {
+		b.logger.Info(key, "write", len(items), "items success, skipped", len(skipped), "failed", len(failed))
+	}
+
+	isSkipped := make(map[pkg.Item]struct{}, len(skipped))
+	for _, item := range skipped {
+		isSkipped[item] = struct{}{}
+	}
+	var currentErr error
+	for _, item := range items {
+		task := item.GetContext().GetTask()
+		itemErr := err
+		if itemErr == nil {
+			itemErr = failed[item]
+		}
+		if itemErr != nil {
+			task.IncItemError()
+		} else if _, ok := isSkipped[item]; ok {
+			task.IncItemIgnore()
+		} else {
+			task.IncItemSuccess()
+		}
+		if item == current {
+			currentErr = itemErr
+		} else if itemErr != nil {
+			item.GetContext().GetItem().
+				WithStatus(pkg.ItemStatusFailure).
+				WithStopReason(itemErr.Error())
+			b.crawler.GetSignal().ItemChanged(item)
+		}
+		task.ItemOut()
+	}
+	return currentErr
+}
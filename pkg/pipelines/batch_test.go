@@ -0,0 +1,94 @@
+package pipelines
+
+import (
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/config"
+	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	"testing"
+)
+
+type testTask struct {
+	pkg.ContextTask
+	success, ignore, error, out int
+}
+
+func (t *testTask) IncItemSuccess() uint32 { t.success++; return 0 }
+func (t *testTask) IncItemIgnore() uint32  { t.ignore++; return 0 }
+func (t *testTask) IncItemError() uint32   { t.error++; return 0 }
+func (t *testTask) ItemOut()               { t.out++ }
+
+type testSignal struct {
+	pkg.Signal
+}
+
+func (testSignal) ItemChanged(pkg.Item) {}
+
+type testCrawler struct {
+	pkg.Crawler
+}
+
+func (testCrawler) GetSignal() pkg.Signal { return testSignal{} }
+
+type testItem struct {
+	pkg.Item
+	ctx pkg.Context
+}
+
+func (i *testItem) GetContext() pkg.Context { return i.ctx }
+
+func TestBatcher_Flush(t *testing.T) {
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := new(testTask)
+	buffered := make([]pkg.Item, 4)
+	for i := range buffered {
+		buffered[i] = &testItem{ctx: new(crawlerContext.Context).
+			WithTask(task).
+			WithItem(new(crawlerContext.Item))}
+	}
+
+	// one item fails and one is a duplicate, the others are written
+	errBad := errors.New("bad row")
+	b := &batcher{
+		write: func(key string, items []pkg.Item) (skipped []pkg.Item, err error) {
+			return []pkg.Item{items[1]}, itemErrors{items[2]: errBad}
+		},
+		crawler: testCrawler{},
+		logger:  logger,
+	}
+	if err = b.flush("test", buffered, buffered[0]); err != nil {
+		t.Errorf("the current item is written, got %v", err)
+	}
+	if task.success != 2 || task.ignore != 1 || task.error != 1 || task.out != 4 {
+		t.Errorf("got %+v", task)
+	}
+	if status := buffered[2].GetContext().GetItem().GetStatus(); status != pkg.ItemStatusFailure {
+		t.Errorf("got %v, want the failed item marked", status)
+	}
+	if status := buffered[3].GetContext().GetItem().GetStatus(); status == pkg.ItemStatusFailure {
+		t.Error("the written item shouldn't be marked")
+	}
+	if err = b.flush("test", buffered, buffered[2]); !errors.Is(err, errBad) {
+		t.Errorf("got %v, want the error of the current item", err)
+	}
+
+	// the whole batch fails
+	*task = testTask{}
+	b.write = func(key string, items []pkg.Item) (skipped []pkg.Item, err error) {
+		return nil, errBad
+	}
+	if err = b.flush("test", buffered, buffered[0]); !errors.Is(err, errBad) {
+		t.Errorf("got %v", err)
+	}
+	if task.error != 4 {
+		t.Errorf("got %+v", task)
+	}
+}
@@ -17,6 +17,8 @@ type KafkaPipeline struct {
 	logger      pkg.Logger
 	kafkaWriter *kafka.Writer
 	timeout     time.Duration
+
+	batcher *batcher
 }
 
 func (m *KafkaPipeline) ProcessItem(item pkg.Item) (err error) {
@@ -57,40 +59,47 @@ func (m *KafkaPipeline) ProcessItem(item pkg.Item) (err error) {
 
 	item.GetContext().GetItem().WithSaved(true)
 
-	bs, err := json.Marshal(data)
-	if err != nil {
-		m.logger.Error(err)
-		task.IncItemError()
-		return
-	}
-
 	if m.env == "dev" {
 		m.logger.Debug("current mode don't need save")
 		task.IncItemIgnore()
 		return
 	}
 
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	return m.batcher.add(itemKafka.GetTopic(), item)
+}
+
+// write sends the items to the topic in one batch.
+func (m *KafkaPipeline) write(topic string, buffered []pkg.Item) (skipped []pkg.Item, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
 	defer cancel()
 
-	m.kafkaWriter.Topic = itemKafka.GetTopic()
-	m.kafkaWriter.Logger = kafka.LoggerFunc(func(msg string, a ...interface{}) {
-		m.logger.Info(itemKafka.GetTopic(), "insert success", a)
-	})
-	err = m.kafkaWriter.WriteMessages(ctx,
-		kafka.Message{
+	messages := make([]kafka.Message, len(buffered))
+	for i, item := range buffered {
+		itemKafka := item.GetItem().(*items.ItemKafka)
+
+		var bs []byte
+		if bs, err = json.Marshal(itemKafka.Data()); err != nil {
+			return
+		}
+		messages[i] = kafka.Message{
+			Topic: topic,
 			Key:   []byte(fmt.Sprint(itemKafka.Id())),
 			Value: bs,
-		},
-	)
-	if err != nil {
-		m.logger.Error(err)
-		task.IncItemError()
-		return
+		}
 	}
 
-	task.IncItemSuccess()
+	err = m.kafkaWriter.WriteMessages(ctx, messages...)
+	return
+}
+
+func (m *KafkaPipeline) spiderClosed(ctx pkg.Context) (err error) {
+	if ctx.GetSpider().GetName() != m.Spider().Name() {
+		return
+	}
+	if ctx.GetSpider().GetStatus() != pkg.SpiderStatusStopped {
+		return
+	}
+	err = m.kafkaWriter.Close()
 	return
 }
 
@@ -105,11 +114,20 @@ func (m *KafkaPipeline) FromSpider(spider pkg.Spider) (err error) {
 	crawler := spider.GetCrawler()
 	m.env = spider.GetConfig().GetEnv()
 	m.logger = spider.GetLogger()
-	m.kafkaWriter = crawler.GetKafka()
-	if m.kafkaWriter == nil {
+	kafkaWriter := crawler.GetKafka()
+	if kafkaWriter == nil {
 		err = errors.New("kafkaWriter nil")
 		return
 	}
+	// the shared writer has the topic of the scheduler, while the topics of the batches are set by the messages
+	m.kafkaWriter = &kafka.Writer{
+		Addr:                   kafkaWriter.Addr,
+		AllowAutoTopicCreation: true,
+		BatchSize:              spider.GetConfig().GetPipelineBatchSize(),
+		BatchTimeout:           10 * time.Millisecond,
+	}
 	m.timeout = time.Minute
+	m.batcher = newBatcher(spider, m.write)
+	crawler.GetSignal().RegisterSpiderChanged(m.spiderClosed)
 	return
 }
@@ -7,16 +7,22 @@ import (
 	"github.com/lizongying/go-crawler/pkg/items"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"reflect"
 	"time"
 )
 
+// mongoErrDuplicateKey is the code of a duplicate key.
+const mongoErrDuplicateKey = 11000
+
 type MongoPipeline struct {
 	pkg.UnimplementedPipeline
 	env     string
 	logger  pkg.Logger
 	mongoDb *mongo.Database
 	timeout time.Duration
+
+	batcher *batcher
 }
 
 func (m *MongoPipeline) ProcessItem(item pkg.Item) (err error) {
@@ -57,41 +63,62 @@ func (m *MongoPipeline) ProcessItem(item pkg.Item) (err error) {
 
 	item.GetContext().GetItem().WithSaved(true)
 
-	bs, err := bson.Marshal(data)
-	if err != nil {
-		m.logger.Error(err)
-		task.IncItemError()
-		return
-	}
-
 	if m.env == "dev" {
 		m.logger.Debug("current mode don't need save")
 		task.IncItemIgnore()
 		return
 	}
 
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	return m.batcher.add(itemMongo.GetCollection(), item)
+}
+
+// write inserts the items to the collection with an unordered bulk write.
+// The items with update and id are upserted by the id.
+// The bulk write goes on after a failed item, so only the failed items are reported, and the duplicates are skipped.
+func (m *MongoPipeline) write(collection string, buffered []pkg.Item) (skipped []pkg.Item, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
 	defer cancel()
 
-	res, err := m.mongoDb.Collection(itemMongo.GetCollection()).InsertOne(ctx, bs)
-	if err != nil {
-		if itemMongo.GetUpdate() && !reflect.ValueOf(itemMongo.Id()).IsZero() && mongo.IsDuplicateKeyError(err) {
-			_, err = m.mongoDb.Collection(itemMongo.GetCollection()).UpdateOne(ctx, bson.M{"_id": itemMongo.Id()}, bson.M{"$set": itemMongo.Data()})
-			if err == nil {
-				m.logger.Info(itemMongo.GetCollection(), "update success", itemMongo.Id())
+	models := make([]mongo.WriteModel, len(buffered))
+	for i, item := range buffered {
+		itemMongo := item.GetItem().(*items.ItemMongo)
+		if itemMongo.GetUpdate() && !reflect.ValueOf(itemMongo.Id()).IsZero() {
+			models[i] = mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": itemMongo.Id()}).
+				SetUpdate(bson.M{"$set": itemMongo.Data()}).
+				SetUpsert(true)
+			continue
+		}
+
+		var bs []byte
+		if bs, err = bson.Marshal(itemMongo.Data()); err != nil {
+			return
+		}
+		models[i] = mongo.NewInsertOneModel().SetDocument(bson.Raw(bs))
+	}
+
+	res, err := m.mongoDb.Collection(collection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil {
+		failed := make(itemErrors)
+		for _, e := range bulkErr.WriteErrors {
+			if e.Index < 0 || e.Index >= len(buffered) {
+				continue
+			}
+			if e.Code == mongoErrDuplicateKey {
+				skipped = append(skipped, buffered[e.Index])
+				continue
 			}
+			failed[buffered[e.Index]] = e
+		}
+		err = nil
+		if len(failed) > 0 {
+			err = failed
 		}
-	} else {
-		m.logger.Info(itemMongo.GetCollection(), "insert success", res.InsertedID)
 	}
-	if err != nil {
-		m.logger.Error(err)
-		task.IncItemError()
-		return
+	if res != nil {
+		m.logger.Info(collection, "inserted", res.InsertedCount, "upserted", res.UpsertedCount, "modified", res.ModifiedCount)
 	}
-
-	task.IncItemSuccess()
 	return
 }
 
@@ -112,5 +139,6 @@ func (m *MongoPipeline) FromSpider(spider pkg.Spider) (err error) {
 		return
 	}
 	m.timeout = time.Minute
+	m.batcher = newBatcher(spider, m.write)
 	return
 }
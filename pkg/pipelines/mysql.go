@@ -13,12 +13,17 @@ import (
 	"time"
 )
 
+// mysqlErrDupEntry is the error number of a duplicate key.
+const mysqlErrDupEntry = 1062
+
 type MysqlPipeline struct {
 	pkg.UnimplementedPipeline
 	env     string
 	logger  pkg.Logger
 	mysql   *sql.DB
 	timeout time.Duration
+
+	batcher *batcher
 }
 
 func (m *MysqlPipeline) ProcessItem(item pkg.Item) (err error) {
@@ -65,84 +70,132 @@ func (m *MysqlPipeline) ProcessItem(item pkg.Item) (err error) {
 		return
 	}
 
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	return m.batcher.add(itemMysql.GetTable(), item)
+}
+
+// write inserts the items to the table in a transaction, with a multi-row statement for each set of columns.
+// The rows of the duplicate keys are updated for the items with update.
+// If the batch fails, e.g. by a duplicate key, the rows are inserted one by one, so only the bad rows fail.
+func (m *MysqlPipeline) write(table string, buffered []pkg.Item) (skipped []pkg.Item, err error) {
+	if len(buffered) > 1 {
+		if err = m.insertBatch(table, buffered); err == nil {
+			return
+		}
+		m.logger.Warn(table, "insert", len(buffered), "rows failed, insert them one by one.", err)
+	}
+	return m.insertRows(table, buffered)
+}
+
+// insertRows inserts the items one by one, the items of the duplicate keys are skipped.
+func (m *MysqlPipeline) insertRows(table string, buffered []pkg.Item) (skipped []pkg.Item, err error) {
+	failed := make(itemErrors)
+	for _, item := range buffered {
+		itemMysql := item.GetItem().(*items.ItemMysql)
+		columns, values := mysqlColumns(itemMysql.Data())
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		_, e := m.mysql.ExecContext(ctx, mysqlInsert(table, columns, 1, itemMysql.GetUpdate()), values...)
+		cancel()
+
+		var mysqlErr *mysql.MySQLError
+		switch {
+		case e == nil:
+		case errors.As(e, &mysqlErr) && mysqlErr.Number == mysqlErrDupEntry:
+			skipped = append(skipped, item)
+		default:
+			failed[item] = e
+		}
+	}
+	if len(failed) > 0 {
+		err = failed
+	}
+	return
+}
+
+// insertBatch inserts the items in a transaction, it fails as a whole.
+func (m *MysqlPipeline) insertBatch(table string, buffered []pkg.Item) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
 	defer cancel()
 
-	refType := reflect.TypeOf(itemMysql.Data()).Elem()
-	refValue := reflect.ValueOf(itemMysql.Data()).Elem()
-	var columns []string
-	var values []any
-	for i := 0; i < refType.NumField(); i++ {
-		column := refType.Field(i).Tag.Get("column")
-		if column == "" {
-			column = refType.Field(i).Name
+	// the rows are grouped by the columns and update, each group is inserted by one statement
+	type group struct {
+		columns []string
+		update  bool
+		values  []any
+		rows    int
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	for _, item := range buffered {
+		itemMysql := item.GetItem().(*items.ItemMysql)
+
+		columns, values := mysqlColumns(itemMysql.Data())
+		key := fmt.Sprint(columns, itemMysql.GetUpdate())
+		g, ok := index[key]
+		if !ok {
+			g = &group{columns: columns, update: itemMysql.GetUpdate()}
+			index[key] = g
+			groups = append(groups, g)
 		}
-		columns = append(columns, fmt.Sprintf("%s=?", column))
-		value := refValue.Field(i).Interface()
-		values = append(values, value)
+		g.values = append(g.values, values...)
+		g.rows++
 	}
 
-	s := fmt.Sprintf(`INSERT %s SET %s`, itemMysql.GetTable(), strings.Join(columns, ","))
-	stmt, err := m.mysql.PrepareContext(ctx, s)
+	tx, err := m.mysql.BeginTx(ctx, nil)
 	if err != nil {
-		m.logger.Error(err)
-		task.IncItemError()
 		return
 	}
-	res, err := stmt.ExecContext(ctx, values...)
-	if err != nil {
-		var e *mysql.MySQLError
-		o := errors.As(err, &e)
-		if !o {
-			m.logger.Error(err)
-			task.IncItemError()
-			return
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
 		}
+	}()
 
-		if itemMysql.GetUpdate() && !reflect.ValueOf(itemMysql.Id()).IsZero() && e.Number == 1062 {
-			s = fmt.Sprintf(`UPDATE %s SET %s WHERE id=?`, itemMysql.GetTable(), strings.Join(columns, ","))
-			values = append(values, itemMysql.Id())
-			stmt, err = m.mysql.PrepareContext(ctx, s)
-			if err != nil {
-				m.logger.Error(err)
-				task.IncItemError()
-				return
-			}
-
-			res, err = stmt.ExecContext(ctx, values...)
-			if err != nil {
-				m.logger.Error(err)
-				task.IncItemError()
-				return
-			}
-
-			_, err = res.RowsAffected()
-			if err != nil {
-				m.logger.Error(err)
-				task.IncItemError()
-				return
-			}
-
-			m.logger.Info(itemMysql.GetTable(), "update success", itemMysql.Id())
-		} else {
-			m.logger.Error(err)
-			task.IncItemError()
+	for _, g := range groups {
+		if _, err = tx.ExecContext(ctx, mysqlInsert(table, g.columns, g.rows, g.update), g.values...); err != nil {
 			return
 		}
-	} else {
-		id, e := res.LastInsertId()
-		if e != nil {
-			m.logger.Error(e)
-			task.IncItemError()
-			return
+	}
+	err = tx.Commit()
+	return
+}
+
+// mysqlColumns returns the columns and the values of the fields of the data, which is a pointer to a struct.
+func mysqlColumns(data any) (columns []string, values []any) {
+	refType := reflect.TypeOf(data).Elem()
+	refValue := reflect.ValueOf(data).Elem()
+	for i := 0; i < refType.NumField(); i++ {
+		column := refType.Field(i).Tag.Get("column")
+		if column == "" {
+			column = refType.Field(i).Name
 		}
+		columns = append(columns, column)
+		values = append(values, refValue.Field(i).Interface())
+	}
+	return
+}
 
-		m.logger.Info(itemMysql.GetTable(), "insert success", id)
+// mysqlInsert returns the statement to insert the rows of the columns.
+// The row of the duplicate key is updated if update is true.
+func mysqlInsert(table string, columns []string, rows int, update bool) string {
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	sets := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = fmt.Sprintf("`%s`", column)
+		placeholders[i] = "?"
+		sets[i] = fmt.Sprintf("`%s`=VALUES(`%s`)", column, column)
+	}
+	values := make([]string, rows)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%s)", strings.Join(placeholders, ","))
 	}
 
-	task.IncItemSuccess()
-	return
+	s := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES %s", table, strings.Join(names, ","), strings.Join(values, ","))
+	if update {
+		return fmt.Sprintf("%s ON DUPLICATE KEY UPDATE %s", s, strings.Join(sets, ","))
+	}
+	return s
 }
 
 func (m *MysqlPipeline) FromSpider(spider pkg.Spider) (err error) {
@@ -162,5 +215,6 @@ func (m *MysqlPipeline) FromSpider(spider pkg.Spider) (err error) {
 		return
 	}
 	m.timeout = time.Minute
+	m.batcher = newBatcher(spider, m.write)
 	return
 }
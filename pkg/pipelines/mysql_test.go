@@ -0,0 +1,15 @@
+package pipelines
+
+import "testing"
+
+func TestMysqlInsert(t *testing.T) {
+	s := mysqlInsert("test", []string{"id", "count"}, 2, false)
+	if want := "INSERT INTO `test` (`id`,`count`) VALUES (?,?),(?,?)"; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	s = mysqlInsert("test", []string{"id"}, 1, true)
+	if want := "INSERT INTO `test` (`id`) VALUES (?) ON DUPLICATE KEY UPDATE `id`=VALUES(`id`)"; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
@@ -5,11 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"github.com/go-sql-driver/mysql"
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/items"
-	"reflect"
-	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,6 +17,12 @@ type SqlitePipeline struct {
 	logger  pkg.Logger
 	sqlite  *sql.DB
 	timeout time.Duration
+
+	key         string
+	createTable bool
+	// the statements whose tables have been migrated
+	migrated sync.Map
+	batcher  *batcher
 }
 
 func (m *SqlitePipeline) ProcessItem(item pkg.Item) (err error) {
@@ -65,90 +69,95 @@ func (m *SqlitePipeline) ProcessItem(item pkg.Item) (err error) {
 		return
 	}
 
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, m.timeout)
-	defer cancel()
+	return m.batcher.add(itemSqlite.GetTable(), item)
+}
 
-	refType := reflect.TypeOf(itemSqlite.Data()).Elem()
-	refValue := reflect.ValueOf(itemSqlite.Data()).Elem()
-	var columns []string
-	var columns1 []string
-	var columns2 []string
-	var values []any
-	for i := 0; i < refType.NumField(); i++ {
-		column := refType.Field(i).Tag.Get("column")
-		if column == "" {
-			column = refType.Field(i).Name
-		}
-		columns = append(columns, fmt.Sprintf("%s=?", column))
-		columns1 = append(columns1, fmt.Sprintf("`%s`", column))
-		columns2 = append(columns2, "?")
-		value := refValue.Field(i).Interface()
-		values = append(values, value)
-	}
+// write upserts the items to the table in a transaction.
+// The items without update, whose keys exist, are skipped.
+func (m *SqlitePipeline) write(table string, buffered []pkg.Item) (skipped []pkg.Item, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
 
-	s := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, itemSqlite.GetTable(), strings.Join(columns1, ","), strings.Join(columns2, ","))
-	stmt, err := m.sqlite.PrepareContext(ctx, s)
+	tx, err := m.sqlite.BeginTx(ctx, nil)
 	if err != nil {
-		m.logger.Error(err)
-		task.IncItemError()
 		return
 	}
-	res, err := stmt.ExecContext(ctx, values...)
-	if err != nil {
-		var e *mysql.MySQLError
-		o := errors.As(err, &e)
-		if !o {
-			m.logger.Error(err)
-			task.IncItemError()
-			return
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
 		}
+	}()
 
-		if itemSqlite.GetUpdate() && !reflect.ValueOf(itemSqlite.Id()).IsZero() && e.Number == 1062 {
-			s = fmt.Sprintf(`UPDATE %s SET %s WHERE id=?`, itemSqlite.GetTable(), strings.Join(columns, ","))
-			values = append(values, itemSqlite.Id())
-			stmt, err = m.sqlite.PrepareContext(ctx, s)
-			if err != nil {
-				m.logger.Error(err)
-				task.IncItemError()
-				return
-			}
+	var migrated []string
+	stmts := make(map[string]*sql.Stmt)
+	for _, item := range buffered {
+		itemSqlite := item.GetItem().(*items.ItemSqlite)
 
-			res, err = stmt.ExecContext(ctx, values...)
-			if err != nil {
-				m.logger.Error(err)
-				task.IncItemError()
-				return
+		var columns []sqliteColumn
+		if columns, err = sqliteColumns(item.Data()); err != nil {
+			return
+		}
+		if itemSqlite.GetUpdate() && !hasSqliteColumn(columns, m.key) {
+			err = fmt.Errorf("the key %s isn't in the data", m.key)
+			return
+		}
+
+		s := sqliteInsert(table, m.key, columns, itemSqlite.GetUpdate())
+		stmt, ok := stmts[s]
+		if !ok {
+			if m.createTable {
+				if _, ok = m.migrated.Load(s); !ok {
+					if err = m.migrate(ctx, tx, table, columns); err != nil {
+						return
+					}
+					migrated = append(migrated, s)
+				}
 			}
 
-			_, err = res.RowsAffected()
-			if err != nil {
-				m.logger.Error(err)
-				task.IncItemError()
+			if stmt, err = tx.PrepareContext(ctx, s); err != nil {
 				return
 			}
+			defer func(stmt *sql.Stmt) {
+				_ = stmt.Close()
+			}(stmt)
+			stmts[s] = stmt
+		}
 
-			m.logger.Info(itemSqlite.GetTable(), "update success", itemSqlite.Id())
-		} else {
-			m.logger.Error(err)
-			task.IncItemError()
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			values[i] = column.value
+		}
+		var res sql.Result
+		if res, err = stmt.ExecContext(ctx, values...); err != nil {
 			return
 		}
-	} else {
-		id, e := res.LastInsertId()
-		if e != nil {
-			m.logger.Error(e)
-			task.IncItemError()
+		var affected int64
+		if affected, err = res.RowsAffected(); err != nil {
 			return
 		}
-
-		m.logger.Info(itemSqlite.GetTable(), "insert success", id)
+		if affected == 0 {
+			skipped = append(skipped, item)
+		}
 	}
 
-	task.IncItemSuccess()
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	for _, s := range migrated {
+		m.migrated.Store(s, struct{}{})
+	}
 	return
 }
 
+func hasSqliteColumn(columns []sqliteColumn, name string) bool {
+	for _, column := range columns {
+		if column.name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *SqlitePipeline) FromSpider(spider pkg.Spider) (err error) {
 	if m == nil {
 		return new(SqlitePipeline).FromSpider(spider)
@@ -166,5 +175,8 @@ func (m *SqlitePipeline) FromSpider(spider pkg.Spider) (err error) {
 		return
 	}
 	m.timeout = time.Minute
+	m.key = spider.GetConfig().GetSqlitePipelineKey()
+	m.createTable = spider.GetConfig().GetSqlitePipelineCreateTable()
+	m.batcher = newBatcher(spider, m.write)
 	return
 }
@@ -0,0 +1,171 @@
+package pipelines
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// sqliteColumn is a column of the item data, named by the "column" tag or the field name.
+type sqliteColumn struct {
+	name  string
+	typ   string
+	value any
+}
+
+// sqliteType returns the declared type of the column for the go type.
+// The types without a sqlite equivalent are stored as json text.
+func sqliteType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return "DATETIME"
+	}
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BLOB"
+		}
+	}
+	return "TEXT"
+}
+
+// sqliteValue returns the value which can be bound to the statement.
+func sqliteValue(v reflect.Value) (value any, err error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if sqliteType(v.Type()) != "TEXT" || v.Kind() == reflect.String {
+		value = v.Interface()
+		return
+	}
+
+	bs, err := json.Marshal(v.Interface())
+	if err != nil {
+		return
+	}
+	value = string(bs)
+	return
+}
+
+// sqliteColumns returns the columns of the exported fields of the data, which must be a pointer to a struct.
+// The fields tagged with `column:"-"` are skipped.
+func sqliteColumns(data any) (columns []sqliteColumn, err error) {
+	refValue := reflect.ValueOf(data)
+	if refValue.Kind() != reflect.Ptr || refValue.Elem().Kind() != reflect.Struct {
+		err = errors.New("data must be a pointer to a struct")
+		return
+	}
+	refValue = refValue.Elem()
+	refType := refValue.Type()
+
+	for i := 0; i < refType.NumField(); i++ {
+		field := refType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		column := field.Tag.Get("column")
+		if column == "-" {
+			continue
+		}
+		if column == "" {
+			column = field.Name
+		}
+
+		var value any
+		if value, err = sqliteValue(refValue.Field(i)); err != nil {
+			return
+		}
+		columns = append(columns, sqliteColumn{
+			name:  column,
+			typ:   sqliteType(field.Type),
+			value: value,
+		})
+	}
+	return
+}
+
+// sqliteInsert returns the statement to insert the columns.
+// The row of the same key is updated if update is true, otherwise the row is ignored.
+func sqliteInsert(table string, key string, columns []sqliteColumn, update bool) string {
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	var sets []string
+	for i, column := range columns {
+		names[i] = fmt.Sprintf("`%s`", column.name)
+		placeholders[i] = "?"
+		if column.name != key {
+			sets = append(sets, fmt.Sprintf("`%s`=excluded.`%s`", column.name, column.name))
+		}
+	}
+
+	s := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES (%s)", table, strings.Join(names, ","), strings.Join(placeholders, ","))
+	if update && len(sets) > 0 {
+		return fmt.Sprintf("%s ON CONFLICT(`%s`) DO UPDATE SET %s", s, key, strings.Join(sets, ","))
+	}
+	return fmt.Sprintf("%s ON CONFLICT DO NOTHING", s)
+}
+
+// sqliteCreateTable returns the statement to create the table, with the key as the primary key.
+func sqliteCreateTable(table string, key string, columns []sqliteColumn) string {
+	definitions := make([]string, len(columns))
+	for i, column := range columns {
+		definitions[i] = fmt.Sprintf("`%s` %s", column.name, column.typ)
+		if column.name == key {
+			definitions[i] += " PRIMARY KEY"
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` (%s)", table, strings.Join(definitions, ","))
+}
+
+// migrate creates the table if it doesn't exist, and adds the columns it doesn't have.
+func (m *SqlitePipeline) migrate(ctx context.Context, tx *sql.Tx, table string, columns []sqliteColumn) (err error) {
+	if _, err = tx.ExecContext(ctx, sqliteCreateTable(table, m.key, columns)); err != nil {
+		return
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", strings.ReplaceAll(table, "'", "''")))
+	if err != nil {
+		return
+	}
+	existing := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return
+		}
+		existing[name] = struct{}{}
+	}
+	if err = rows.Close(); err != nil {
+		return
+	}
+
+	for _, column := range columns {
+		if _, ok := existing[column.name]; ok {
+			continue
+		}
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, column.name, column.typ)); err != nil {
+			return
+		}
+		m.logger.Info(table, "add column", column.name, column.typ)
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package pipelines
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"testing"
+	"time"
+)
+
+type sqliteTestData struct {
+	Id      string            `column:"id"`
+	Count   int               `column:"count"`
+	Score   *float64          `column:"score"`
+	Tags    []string          `column:"tags"`
+	Time    time.Time         `column:"time"`
+	Skipped map[string]string `column:"-"`
+	private string
+}
+
+func TestSqliteColumns(t *testing.T) {
+	columns, err := sqliteColumns(&sqliteTestData{Id: "1", Count: 2, Tags: []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name string
+		typ  string
+	}{
+		{"id", "TEXT"},
+		{"count", "INTEGER"},
+		{"score", "REAL"},
+		{"tags", "TEXT"},
+		{"time", "DATETIME"},
+	}
+	if len(columns) != len(want) {
+		t.Fatalf("got %d columns, want %d", len(columns), len(want))
+	}
+	for i, w := range want {
+		if columns[i].name != w.name || columns[i].typ != w.typ {
+			t.Errorf("column %d = %s %s, want %s %s", i, columns[i].name, columns[i].typ, w.name, w.typ)
+		}
+	}
+	if columns[2].value != nil {
+		t.Errorf("score = %v, want nil", columns[2].value)
+	}
+	if columns[3].value != `["a"]` {
+		t.Errorf("tags = %v, want json", columns[3].value)
+	}
+
+	if _, err = sqliteColumns(sqliteTestData{}); err == nil {
+		t.Error("want an error for the non-pointer data")
+	}
+}
+
+func TestSqliteUpsert(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	upsert := func(data *sqliteTestData, update bool) (affected int64) {
+		columns, err := sqliteColumns(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.ExecContext(ctx, sqliteCreateTable("test", "id", columns)); err != nil {
+			t.Fatal(err)
+		}
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			values[i] = column.value
+		}
+		res, err := db.ExecContext(ctx, sqliteInsert("test", "id", columns, update), values...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	count := func() (count int) {
+		if err = db.QueryRowContext(ctx, "SELECT count FROM test WHERE id='1'").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	upsert(&sqliteTestData{Id: "1", Count: 1}, false)
+	if a := upsert(&sqliteTestData{Id: "1", Count: 2}, false); a != 0 {
+		t.Errorf("affected = %d, want 0 for the skipped row", a)
+	}
+	if c := count(); c != 1 {
+		t.Errorf("count = %d, want 1 without update", c)
+	}
+
+	upsert(&sqliteTestData{Id: "1", Count: 3}, true)
+	if c := count(); c != 3 {
+		t.Errorf("count = %d, want 3 with update", c)
+	}
+}
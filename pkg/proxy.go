@@ -0,0 +1,9 @@
+package pkg
+
+type ProxyStrategy string
+
+const (
+	ProxyStrategyRoundRobin ProxyStrategy = "round_robin"
+	ProxyStrategyRandom     ProxyStrategy = "random"
+	ProxyStrategySticky     ProxyStrategy = "sticky" // same slot same proxy
+)
@@ -0,0 +1,259 @@
+package proxy
+
+import (
+	"bufio"
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	"math/rand"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNoProxy = errors.New("no available proxy")
+
+type Stat struct {
+	Success     uint32    `json:"success"`
+	Failure     uint32    `json:"failure"`
+	BannedUntil time.Time `json:"banned_until,omitempty"`
+}
+
+type proxy struct {
+	url         *url.URL
+	success     uint32
+	failure     uint32
+	failures    uint8 // consecutive failures
+	bannedUntil time.Time
+}
+
+func (p *proxy) available(now time.Time) bool {
+	return !now.Before(p.bannedUntil)
+}
+
+// Pool is a pool of proxies.
+// A proxy is banned for banTime after maxFailures consecutive failures.
+type Pool struct {
+	mutex       sync.Mutex
+	proxies     []*proxy
+	index       map[string]*proxy
+	next        int
+	slots       map[string]*proxy
+	strategy    pkg.ProxyStrategy
+	maxFailures uint8
+	banTime     time.Duration
+}
+
+// Set replaces the proxies in the pool, stats of the proxies already in the pool are kept.
+func (p *Pool) Set(urls []string) (err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	proxies := make([]*proxy, 0, len(urls))
+	index := make(map[string]*proxy, len(urls))
+	for _, v := range urls {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		var u *url.URL
+		u, err = url.Parse(v)
+		if err != nil {
+			return
+		}
+		key := u.String()
+		if _, ok := index[key]; ok {
+			continue
+		}
+
+		pr, ok := p.index[key]
+		if !ok {
+			pr = &proxy{url: u}
+		}
+		proxies = append(proxies, pr)
+		index[key] = pr
+	}
+
+	p.proxies = proxies
+	p.index = index
+	for slot, pr := range p.slots {
+		if _, ok := index[pr.url.String()]; !ok {
+			delete(p.slots, slot)
+		}
+	}
+	return
+}
+
+func (p *Pool) Len() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.proxies)
+}
+
+// Contains reports whether the proxy belongs to the pool.
+func (p *Pool) Contains(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	_, ok := p.index[u.String()]
+	return ok
+}
+
+// Get returns an available proxy according to the strategy.
+// slot is only used by the sticky strategy.
+func (p *Pool) Get(slot string) (u *url.URL, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := time.Now()
+	if p.strategy == pkg.ProxyStrategySticky {
+		if pr, ok := p.slots[slot]; ok && pr.available(now) {
+			u = pr.url
+			return
+		}
+	}
+
+	var available []*proxy
+	for _, pr := range p.proxies {
+		if pr.available(now) {
+			available = append(available, pr)
+		}
+	}
+	if len(available) == 0 {
+		err = ErrNoProxy
+		return
+	}
+
+	var pr *proxy
+	switch p.strategy {
+	case pkg.ProxyStrategyRandom:
+		pr = available[rand.Intn(len(available))]
+	case pkg.ProxyStrategySticky:
+		pr = available[rand.Intn(len(available))]
+		p.slots[slot] = pr
+	default:
+		pr = available[p.next%len(available)]
+		p.next = (p.next + 1) % len(available)
+	}
+
+	u = pr.url
+	return
+}
+
+// Success records a successful response of the proxy.
+func (p *Pool) Success(u *url.URL) {
+	if u == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pr, ok := p.index[u.String()]
+	if !ok {
+		return
+	}
+	pr.success++
+	pr.failures = 0
+}
+
+// Failure records a failure of the proxy, banned is true if the proxy is banned because of it.
+func (p *Pool) Failure(u *url.URL) (banned bool) {
+	if u == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pr, ok := p.index[u.String()]
+	if !ok {
+		return
+	}
+	pr.failure++
+	pr.failures++
+	if pr.failures >= p.maxFailures {
+		pr.failures = 0
+		pr.bannedUntil = time.Now().Add(p.banTime)
+		banned = true
+	}
+	return
+}
+
+// Ban bans the proxy for banTime immediately.
+func (p *Pool) Ban(u *url.URL) {
+	if u == nil {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	pr, ok := p.index[u.String()]
+	if !ok {
+		return
+	}
+	pr.failure++
+	pr.failures = 0
+	pr.bannedUntil = time.Now().Add(p.banTime)
+}
+
+// Stats returns the stats of each proxy, keyed by the proxy url.
+func (p *Pool) Stats() (stats map[string]Stat) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	stats = make(map[string]Stat, len(p.proxies))
+	for _, pr := range p.proxies {
+		stat := Stat{
+			Success: pr.success,
+			Failure: pr.failure,
+		}
+		if pr.bannedUntil.After(time.Now()) {
+			stat.BannedUntil = pr.bannedUntil
+		}
+		stats[pr.url.String()] = stat
+	}
+	return
+}
+
+// ReadFile reads proxies from a file, one per line. Empty lines and lines starting with # are skipped.
+func ReadFile(name string) (urls []string, err error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	err = scanner.Err()
+	return
+}
+
+func NewPool(strategy pkg.ProxyStrategy, maxFailures uint8, banTime time.Duration) *Pool {
+	if maxFailures < 1 {
+		maxFailures = 1
+	}
+	return &Pool{
+		index:       make(map[string]*proxy),
+		slots:       make(map[string]*proxy),
+		strategy:    strategy,
+		maxFailures: maxFailures,
+		banTime:     banTime,
+	}
+}
@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"testing"
+	"time"
+)
+
+func TestPool_RoundRobin(t *testing.T) {
+	p := NewPool(pkg.ProxyStrategyRoundRobin, 2, time.Minute)
+	if err := p.Set([]string{"http://127.0.0.1:1", "http://127.0.0.1:2", "http://127.0.0.1:2"}); err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 2 {
+		t.Fatalf("len %d, want 2", p.Len())
+	}
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		u, err := p.Get("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, u.String())
+	}
+	if got[0] == got[1] || got[0] != got[2] || got[1] != got[3] {
+		t.Fatalf("not round robin: %v", got)
+	}
+}
+
+func TestPool_Ban(t *testing.T) {
+	p := NewPool(pkg.ProxyStrategyRoundRobin, 2, time.Minute)
+	_ = p.Set([]string{"http://127.0.0.1:1", "http://127.0.0.1:2"})
+
+	u, _ := p.Get("")
+	if p.Failure(u) {
+		t.Fatal("banned after 1 failure")
+	}
+	if !p.Failure(u) {
+		t.Fatal("not banned after 2 failures")
+	}
+	for i := 0; i < 3; i++ {
+		v, err := p.Get("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.String() == u.String() {
+			t.Fatal("got banned proxy")
+		}
+	}
+
+	v, _ := p.Get("")
+	p.Ban(v)
+	if _, err := p.Get(""); err != ErrNoProxy {
+		t.Fatalf("err %v, want %v", err, ErrNoProxy)
+	}
+
+	stats := p.Stats()
+	if stats[u.String()].Failure != 2 || stats[u.String()].BannedUntil.IsZero() {
+		t.Fatalf("stats %+v", stats[u.String()])
+	}
+}
+
+func TestPool_Sticky(t *testing.T) {
+	p := NewPool(pkg.ProxyStrategySticky, 1, time.Minute)
+	_ = p.Set([]string{"http://127.0.0.1:1", "http://127.0.0.1:2", "http://127.0.0.1:3"})
+
+	u, _ := p.Get("a")
+	for i := 0; i < 5; i++ {
+		v, _ := p.Get("a")
+		if v.String() != u.String() {
+			t.Fatalf("slot changed proxy: %s -> %s", u, v)
+		}
+	}
+
+	p.Failure(u)
+	v, _ := p.Get("a")
+	if v.String() == u.String() {
+		t.Fatal("slot kept banned proxy")
+	}
+}
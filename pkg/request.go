@@ -90,6 +90,10 @@ type Request interface {
 	SetOkHttpCodes([]int) Request
 	GetSlot() string
 	SetSlot(string) Request
+	GetCookieJar() string
+	SetCookieJar(string) Request
+	GetSession() string
+	SetSession(string) Request
 	GetConcurrency() *uint8
 	SetConcurrency(*uint8) Request
 	GetInterval() time.Duration
@@ -111,6 +115,10 @@ type Request interface {
 	SetClient(Client) Request
 	IsAjax() bool
 	SetAjax(bool) Request
+	GetActions() []Action
+	SetActions(...Action) Request
+	GetCaptures() []string
+	SetCaptures(...string) Request
 	Err() map[string]error
 	SetUrl(string) Request
 	GetUrl() string
@@ -0,0 +1,112 @@
+package request
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/lizongying/go-crawler/pkg"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Fingerprinter computes the fingerprint of a request,
+// requests with the same fingerprint are treated as duplicates by the filter.
+type Fingerprinter struct {
+	ignoreParams []string // query params ignored, a trailing * matches a prefix, e.g. utm_*
+	headers      []string // headers included
+	body         bool     // whether the body is included
+}
+
+func (f *Fingerprinter) ignore(param string) bool {
+	for _, v := range f.ignoreParams {
+		if strings.HasSuffix(v, "*") {
+			if strings.HasPrefix(param, strings.TrimSuffix(v, "*")) {
+				return true
+			}
+			continue
+		}
+		if param == v {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalUrl lowercases the scheme and host, removes the default port, the fragment and the ignored params,
+// and sorts the query.
+func (f *Fingerprinter) CanonicalUrl(u *url.URL) string {
+	c := *u
+	c.Scheme = strings.ToLower(c.Scheme)
+	host := strings.ToLower(c.Hostname())
+	port := c.Port()
+	if (c.Scheme == "http" && port == "80") || (c.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host = host + ":" + port
+	}
+	c.Host = host
+	c.Fragment = ""
+	c.RawFragment = ""
+	if c.Path == "" {
+		c.Path = "/"
+		c.RawPath = ""
+	}
+
+	query := c.Query()
+	for k := range query {
+		if f.ignore(k) {
+			delete(query, k)
+		}
+	}
+	for _, v := range query {
+		sort.Strings(v)
+	}
+	// url.Values.Encode sorts by key
+	c.RawQuery = query.Encode()
+	c.ForceQuery = false
+	return c.String()
+}
+
+// Fingerprint returns the hex sha1 of the method, the canonical url, the selected headers and the body.
+func (f *Fingerprinter) Fingerprint(request pkg.Request) string {
+	method := request.GetMethod()
+	if method == "" {
+		// the same as the http middleware
+		if request.GetBodyStr() != "" {
+			method = http.MethodPost
+		} else {
+			method = http.MethodGet
+		}
+	}
+
+	h := sha1.New()
+	h.Write([]byte(strings.ToUpper(method)))
+	h.Write([]byte{'\n'})
+	if u := request.GetURL(); u != nil {
+		h.Write([]byte(f.CanonicalUrl(u)))
+	}
+	h.Write([]byte{'\n'})
+	for _, k := range f.headers {
+		h.Write([]byte(http.CanonicalHeaderKey(k)))
+		h.Write([]byte{':'})
+		h.Write([]byte(request.GetHeader(k)))
+		h.Write([]byte{'\n'})
+	}
+	if f.body {
+		h.Write([]byte(request.GetBodyStr()))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func NewFingerprinter(ignoreParams []string, headers []string, body bool) *Fingerprinter {
+	return &Fingerprinter{
+		ignoreParams: ignoreParams,
+		headers:      headers,
+		body:         body,
+	}
+}
@@ -0,0 +1,38 @@
+package request
+
+import (
+	"testing"
+)
+
+func TestFingerprinter(t *testing.T) {
+	f := NewFingerprinter([]string{"utm_*", "sid"}, nil, true)
+
+	same := [][2]string{
+		{"https://a.com/b?y=2&x=1", "HTTPS://A.com:443/b?x=1&y=2#top"},
+		{"https://a.com/b?x=1&utm_source=c&sid=d", "https://a.com/b?x=1"},
+		{"https://a.com", "https://a.com/"},
+	}
+	for _, v := range same {
+		if f.Fingerprint(NewRequest().SetUrl(v[0])) != f.Fingerprint(NewRequest().SetUrl(v[1])) {
+			t.Errorf("%s and %s should have the same fingerprint", v[0], v[1])
+		}
+	}
+
+	different := [][2]string{
+		{"https://a.com/b?x=1", "https://a.com/b?x=2"},
+		{"https://a.com/b", "http://a.com/b"},
+		{"https://a.com/b", "https://a.com/B"},
+	}
+	for _, v := range different {
+		if f.Fingerprint(NewRequest().SetUrl(v[0])) == f.Fingerprint(NewRequest().SetUrl(v[1])) {
+			t.Errorf("%s and %s should have different fingerprints", v[0], v[1])
+		}
+	}
+
+	if f.Fingerprint(NewRequest().SetUrl("https://a.com/b").SetBodyStr("x=1")) == f.Fingerprint(NewRequest().SetUrl("https://a.com/b").SetBodyStr("x=2")) {
+		t.Error("the body should be included")
+	}
+	if f.Fingerprint(NewRequest().SetUrl("https://a.com/b")) == f.Fingerprint(NewRequest().SetUrl("https://a.com/b").SetMethod("HEAD")) {
+		t.Error("the method should be included")
+	}
+}
@@ -62,6 +62,10 @@ type Request struct {
 	Fingerprint        string              `json:"fingerprint,omitempty"`
 	Client             pkg.Client          `json:"client,omitempty"`
 	Ajax               bool                `json:"ajax,omitempty"`
+	CookieJar          string              `json:"cookie_jar,omitempty"` // the jar of the cookies, default is pkg.CookieJarDefault
+	Session            string              `json:"session,omitempty"`    // the name of the session, or pkg.SessionAny
+	Actions            []pkg.Action        `json:"actions,omitempty"`
+	Captures           []string            `json:"captures,omitempty"` // regexps of the urls of the xhr/fetch responses to capture by the browser
 }
 
 func (r *Request) GetUniqueKey() string {
@@ -159,6 +163,10 @@ func (r *Request) IsProxyEnable() *bool {
 	return r.ProxyEnable
 }
 func (r *Request) SetProxy(proxy string) pkg.Request {
+	if proxy == "" {
+		r.Proxy = utils.Url{}
+		return r
+	}
 	u, err := url.Parse(proxy)
 	if err == nil {
 		r.SetProxyEnable(true)
@@ -211,6 +219,20 @@ func (r *Request) SetSlot(slot string) pkg.Request {
 func (r *Request) GetSlot() string {
 	return r.Slot
 }
+func (r *Request) SetCookieJar(cookieJar string) pkg.Request {
+	r.CookieJar = cookieJar
+	return r
+}
+func (r *Request) GetCookieJar() string {
+	return r.CookieJar
+}
+func (r *Request) SetSession(session string) pkg.Request {
+	r.Session = session
+	return r
+}
+func (r *Request) GetSession() string {
+	return r.Session
+}
 func (r *Request) SetConcurrency(concurrency *uint8) pkg.Request {
 	r.Concurrency = concurrency
 	return r
@@ -287,6 +309,20 @@ func (r *Request) SetAjax(ajax bool) pkg.Request {
 	r.Ajax = ajax
 	return r
 }
+func (r *Request) GetActions() []pkg.Action {
+	return r.Actions
+}
+func (r *Request) SetActions(actions ...pkg.Action) pkg.Request {
+	r.Actions = actions
+	return r
+}
+func (r *Request) GetCaptures() []string {
+	return r.Captures
+}
+func (r *Request) SetCaptures(captures ...string) pkg.Request {
+	r.Captures = captures
+	return r
+}
 func (r *Request) setErr(key string, value error) {
 	if r.Errors == nil {
 		r.Errors = make(map[string]error)
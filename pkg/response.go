@@ -19,12 +19,20 @@ type Response interface {
 	GetRequest() Request
 	BodyBytes() []byte
 	SetBodyBytes([]byte) Response
+	GetError() error
+	SetError(error) Response
 	BodyStr() string
 	SetBodyStr(string) Response
+	GetCharset() string
+	SetCharset(string) Response
 	Files() []File
 	SetFiles([]File) Response
 	Images() []Image
 	SetImages([]Image) Response
+	EvalResults() []any
+	SetEvalResults([]any) Response
+	Captures() []*Capture
+	SetCaptures([]*Capture) Response
 	Headers() http.Header
 	GetHeader(string) string
 	StatusCode() int
@@ -24,6 +24,10 @@ type Response struct {
 	bodyBytes []byte
 	files     []pkg.File
 	images    []pkg.Image
+	charset   string
+	results   []any
+	captures  []*pkg.Capture
+	err       error // the error of the download, if the response is nil
 }
 
 func (r *Response) SetResponse(response *http.Response) pkg.Response {
@@ -47,6 +51,13 @@ func (r *Response) SetBodyBytes(bodyBytes []byte) pkg.Response {
 	r.bodyBytes = bodyBytes
 	return r
 }
+func (r *Response) GetError() error {
+	return r.err
+}
+func (r *Response) SetError(err error) pkg.Response {
+	r.err = err
+	return r
+}
 func (r *Response) BodyStr() string {
 	return string(r.bodyBytes)
 }
@@ -54,6 +65,27 @@ func (r *Response) SetBodyStr(bodyStr string) pkg.Response {
 	r.bodyBytes = []byte(bodyStr)
 	return r
 }
+func (r *Response) GetCharset() string {
+	return r.charset
+}
+func (r *Response) SetCharset(charset string) pkg.Response {
+	r.charset = charset
+	return r
+}
+func (r *Response) EvalResults() []any {
+	return r.results
+}
+func (r *Response) SetEvalResults(results []any) pkg.Response {
+	r.results = results
+	return r
+}
+func (r *Response) Captures() []*pkg.Capture {
+	return r.captures
+}
+func (r *Response) SetCaptures(captures []*pkg.Capture) pkg.Response {
+	r.captures = captures
+	return r
+}
 func (r *Response) SetFiles(files []pkg.File) pkg.Response {
 	r.files = files
 	return r
@@ -0,0 +1,20 @@
+package pkg
+
+type RetryBackoff string
+
+const (
+	RetryBackoffFixed       RetryBackoff = "fixed"       // wait the same delay before each retry
+	RetryBackoffExponential RetryBackoff = "exponential" // double the delay after each retry
+	RetryBackoffJitter      RetryBackoff = "jitter"      // random delay between 0 and the exponential one
+)
+
+type RetryErrorClass string
+
+const (
+	RetryErrorTimeout RetryErrorClass = "timeout"
+	RetryErrorReset   RetryErrorClass = "reset"
+	RetryErrorRefused RetryErrorClass = "refused"
+	RetryErrorDns     RetryErrorClass = "dns"
+	RetryErrorEof     RetryErrorClass = "eof"
+	RetryErrorTls     RetryErrorClass = "tls"
+)
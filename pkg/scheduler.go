@@ -9,6 +9,14 @@ const (
 	SchedulerKafka   SchedulerType = "kafka"
 )
 
+// KafkaStartOffset is where the consumer group starts if it has no committed offset.
+type KafkaStartOffset string
+
+const (
+	KafkaStartOffsetFirst KafkaStartOffset = "first"
+	KafkaStartOffsetLast  KafkaStartOffset = "last"
+)
+
 type Scheduler interface {
 	YieldItem(Context, Item) error
 	Request(Context, Request) (Response, error)
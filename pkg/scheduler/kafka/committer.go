@@ -0,0 +1,145 @@
+package kafka
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"sync"
+	"time"
+)
+
+// partitionOffsets tracks the fetched offsets of a partition in order.
+// Only the offsets before the first unfinished one can be committed.
+type partitionOffsets struct {
+	pending []int64
+	done    map[int64]struct{}
+	// the last offset that can be committed, -1 if none
+	commit int64
+}
+
+// committer commits the offsets of the messages after they're done,
+// once commitBatch messages are done or every commitInterval.
+type committer struct {
+	mu sync.Mutex
+	// flushMu serializes the commits, so an older offset isn't committed after a newer one.
+	flushMu sync.Mutex
+
+	partitions  map[string]map[int]*partitionOffsets
+	uncommitted int
+
+	commitBatch    int
+	commitInterval time.Duration
+	commitMessages func(context.Context, ...kafka.Message) error
+	onError        func(error)
+}
+
+func newCommitter(commitBatch int, commitInterval time.Duration, commitMessages func(context.Context, ...kafka.Message) error, onError func(error)) *committer {
+	return &committer{
+		partitions:     make(map[string]map[int]*partitionOffsets),
+		commitBatch:    commitBatch,
+		commitInterval: commitInterval,
+		commitMessages: commitMessages,
+		onError:        onError,
+	}
+}
+
+func (c *committer) partition(msg kafka.Message) *partitionOffsets {
+	partitions, ok := c.partitions[msg.Topic]
+	if !ok {
+		partitions = make(map[int]*partitionOffsets)
+		c.partitions[msg.Topic] = partitions
+	}
+	p, ok := partitions[msg.Partition]
+	if !ok {
+		p = &partitionOffsets{
+			done:   make(map[int64]struct{}),
+			commit: -1,
+		}
+		partitions[msg.Partition] = p
+	}
+	return p
+}
+
+// fetched must be called in the order the messages are fetched.
+func (c *committer) fetched(msg kafka.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.partition(msg)
+	p.pending = append(p.pending, msg.Offset)
+}
+
+// done marks the message as done, and commits if there are enough done messages.
+func (c *committer) done(msg kafka.Message) {
+	c.mu.Lock()
+	p := c.partition(msg)
+	p.done[msg.Offset] = struct{}{}
+	for len(p.pending) > 0 {
+		if _, ok := p.done[p.pending[0]]; !ok {
+			break
+		}
+		delete(p.done, p.pending[0])
+		p.commit = p.pending[0]
+		p.pending = p.pending[1:]
+	}
+	c.uncommitted++
+	full := c.uncommitted >= c.commitBatch
+	c.mu.Unlock()
+
+	if full {
+		c.flush()
+	}
+}
+
+// committable returns the last done offsets of the partitions, which haven't been committed.
+func (c *committer) committable() (msgs []kafka.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for topic, partitions := range c.partitions {
+		for partition, p := range partitions {
+			if p.commit < 0 {
+				continue
+			}
+			msgs = append(msgs, kafka.Message{
+				Topic:     topic,
+				Partition: partition,
+				Offset:    p.commit,
+			})
+			p.commit = -1
+		}
+	}
+	c.uncommitted = 0
+	return
+}
+
+// flush commits the done offsets.
+func (c *committer) flush() {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	msgs := c.committable()
+	if len(msgs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.commitMessages(ctx, msgs...); err != nil {
+		c.onError(err)
+	}
+}
+
+// run flushes every commitInterval until ctx is done, and flushes at last.
+func (c *committer) run(ctx context.Context) {
+	ticker := time.NewTicker(c.commitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			c.flush()
+			return
+		case <-ticker.C:
+			c.flush()
+		}
+	}
+}
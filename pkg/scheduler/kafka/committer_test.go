@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommitter(t *testing.T) {
+	var committed []kafka.Message
+	c := newCommitter(2, time.Minute, func(_ context.Context, msgs ...kafka.Message) error {
+		committed = append(committed, msgs...)
+		return nil
+	}, func(err error) {
+		t.Fatal(err)
+	})
+
+	msgs := make([]kafka.Message, 4)
+	for i := range msgs {
+		msgs[i] = kafka.Message{Topic: "request", Partition: 0, Offset: int64(i)}
+		c.fetched(msgs[i])
+	}
+
+	// offset 1 can't be committed before offset 0
+	c.done(msgs[1])
+	c.done(msgs[2])
+	if len(committed) != 0 {
+		t.Fatalf("committed %v, want none", committed)
+	}
+
+	c.done(msgs[0])
+	c.flush()
+	if len(committed) != 1 || committed[0].Offset != 2 {
+		t.Fatalf("committed %v, want offset 2", committed)
+	}
+
+	c.flush()
+	if len(committed) != 1 {
+		t.Fatalf("committed %v again", committed)
+	}
+
+	c.done(msgs[3])
+	c.flush()
+	if len(committed) != 2 || committed[1].Offset != 3 {
+		t.Fatalf("committed %v, want offset 3", committed)
+	}
+}
+
+func TestCommitter_Concurrent(t *testing.T) {
+	var last int64 = -1
+	c := newCommitter(1, time.Millisecond, func(_ context.Context, msgs ...kafka.Message) error {
+		for _, v := range msgs {
+			if v.Offset <= last {
+				t.Errorf("committed offset %d after %d", v.Offset, last)
+			}
+			last = v.Offset
+		}
+		// give the other flushes a chance to overtake
+		time.Sleep(time.Microsecond)
+		return nil
+	}, func(err error) {
+		t.Error(err)
+	})
+
+	msgs := make([]kafka.Message, 200)
+	for i := range msgs {
+		msgs[i] = kafka.Message{Topic: "request", Partition: 0, Offset: int64(i)}
+		c.fetched(msgs[i])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		c.run(ctx)
+		close(stopped)
+	}()
+
+	var wg sync.WaitGroup
+	for i := range msgs {
+		wg.Add(1)
+		go func(msg kafka.Message) {
+			defer wg.Done()
+			c.done(msg)
+		}(msgs[i])
+	}
+	wg.Wait()
+	cancel()
+	<-stopped
+
+	if last != 199 {
+		t.Errorf("committed offset %d, want 199", last)
+	}
+}
@@ -9,17 +9,14 @@ import (
 	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
 	request2 "github.com/lizongying/go-crawler/pkg/request"
 	"github.com/segmentio/kafka-go"
-	"golang.org/x/time/rate"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
 
 func (s *Scheduler) handleRequest(ctx pkg.Context) {
-	slot := "*"
-	value, _ := s.spider.RequestSlotLoad(slot)
-	requestSlot := value.(*rate.Limiter)
 out:
 	for {
 		select {
@@ -32,9 +29,11 @@ out:
 				s.logger.Warn(err)
 				continue
 			}
+			s.committer.fetched(req)
 			if len(req.Value) == 0 {
 				err = errors.New("req is empty")
 				s.logger.Warn(err)
+				s.committer.done(req)
 				continue
 			}
 
@@ -42,6 +41,8 @@ out:
 			request := new(request2.Request)
 			if err = request.Unmarshal(req.Value); err != nil {
 				s.logger.Warn(err)
+				s.deadLetter(req, err)
+				s.committer.done(req)
 				continue
 			}
 
@@ -51,24 +52,7 @@ out:
 				s.logger.Warn(err)
 				continue
 			}
-			slot = request.GetSlot()
-			if slot == "" {
-				slot = "*"
-			}
-			slotValue, ok := s.spider.RequestSlotLoad(slot)
-			if !ok {
-				concurrency := uint8(1)
-				if request.GetConcurrency() != nil {
-					concurrency = *request.GetConcurrency()
-				}
-				if concurrency < 1 {
-					concurrency = 1
-				}
-				requestSlot = rate.NewLimiter(rate.Every(request.GetInterval()/time.Duration(concurrency)), int(concurrency))
-				s.spider.RequestSlotStore(slot, requestSlot)
-			}
-
-			requestSlot = slotValue.(*rate.Limiter)
+			requestSlot := s.RequestSlot(request)
 
 			if err = requestSlot.Wait(ctx.GetTask().GetContext()); err != nil {
 				s.logger.Error(err)
@@ -77,10 +61,16 @@ out:
 			s.crawler.GetSignal().RequestChanged(request)
 			go func(c pkg.Context, request pkg.Request) {
 				var response pkg.Response
-				response, err = s.Request(c, request)
+				response, err = s.RequestOnce(c, request)
+				if delay, ok := pkg.RetryDelay(err); ok {
+					s.retryRequest(c, request, delay, req)
+					return
+				}
 				if err != nil {
 					ctx.GetRequest().WithStatus(pkg.RequestStatusFailure).WithStopReason(err.Error())
 					s.crawler.GetSignal().RequestChanged(request)
+					s.deadLetter(req, err)
+					s.committer.done(req)
 					s.task.RequestOut()
 					return
 				}
@@ -92,6 +82,8 @@ out:
 							err = errors.New("panic")
 							s.HandleError(ctx, response, err, request.GetErrBack())
 						}
+						// the errors have been handled by the errback, so it's committed either way
+						s.committer.done(req)
 						s.task.MethodOut()
 						s.task.RequestOut()
 					}()
@@ -112,6 +104,46 @@ out:
 	}
 }
 
+// retryRequest yields the request again after the delay.
+// The message is done once the request is queued again, or sent to the dead letter topic.
+func (s *Scheduler) retryRequest(ctx pkg.Context, request pkg.Request, delay time.Duration, msg kafka.Message) {
+	s.logger.Info("retry after", delay, request.GetUrl())
+	time.AfterFunc(delay, func() {
+		defer s.task.RequestOut()
+		defer s.committer.done(msg)
+
+		if err := s.YieldRequest(ctx, request); err != nil {
+			s.logger.Error(err)
+			ctx.GetRequest().WithStatus(pkg.RequestStatusFailure).WithStopReason(err.Error())
+			s.crawler.GetSignal().RequestChanged(request)
+			s.deadLetter(msg, err)
+		}
+	})
+}
+
+// deadLetter sends the message of a failed request to the dead letter topic, with the error in the header.
+func (s *Scheduler) deadLetter(msg kafka.Message, reason error) {
+	if s.deadLetterWriter == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.deadLetterWriter.WriteMessages(ctx, kafka.Message{
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: append(msg.Headers,
+			kafka.Header{Key: "error", Value: []byte(reason.Error())},
+			kafka.Header{Key: "topic", Value: []byte(msg.Topic)},
+			kafka.Header{Key: "partition", Value: []byte(strconv.Itoa(msg.Partition))},
+			kafka.Header{Key: "offset", Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		),
+	}); err != nil {
+		s.logger.Error("dead letter", err)
+	}
+}
+
 func (s *Scheduler) YieldRequest(ctx pkg.Context, request pkg.Request) (err error) {
 	requestCtx := ctx.GetRequest()
 	if requestCtx != nil {
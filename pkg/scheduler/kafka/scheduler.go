@@ -10,9 +10,11 @@ import (
 type Scheduler struct {
 	scheduler.UnimplementedScheduler
 
-	kafkaReader *kafka.Reader
-	kafkaWriter *kafka.Writer
-	requestKey  string
+	kafkaReader      *kafka.Reader
+	kafkaWriter      *kafka.Writer
+	deadLetterWriter *kafka.Writer
+	committer        *committer
+	requestKey       string
 
 	crawler pkg.Crawler
 	spider  pkg.Spider
@@ -27,6 +29,8 @@ func (s *Scheduler) StartScheduler(ctx pkg.Context) (err error) {
 
 	s.initScheduler(ctx)
 
+	go s.committer.run(ctx.GetTask().GetContext())
+
 	go s.HandleItem(ctx)
 
 	go s.handleRequest(ctx)
@@ -35,18 +39,45 @@ func (s *Scheduler) StartScheduler(ctx pkg.Context) (err error) {
 }
 
 func (s *Scheduler) StopScheduler(_ pkg.Context) (err error) {
+	if s.committer != nil {
+		s.committer.flush()
+	}
+	if s.deadLetterWriter != nil {
+		if err = s.deadLetterWriter.Close(); err != nil {
+			s.logger.Error(err)
+		}
+	}
 	return
 }
 func (s *Scheduler) initScheduler(_ pkg.Context) {
 	s.requestKey = fmt.Sprintf("%s-%s-request", s.config.GetBotName(), s.spider.Name())
 	s.logger.Info("request key", s.requestKey)
 	s.kafkaWriter.Topic = s.requestKey
+
+	startOffset := kafka.FirstOffset
+	if s.config.GetKafkaQueueStartOffset() == pkg.KafkaStartOffsetLast {
+		startOffset = kafka.LastOffset
+	}
+	// the offsets are committed explicitly by the committer
 	s.kafkaReader = kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  s.kafkaReader.Config().Brokers,
-		MaxBytes: 10e6, // 10MB
-		Topic:    s.requestKey,
-		GroupID:  s.config.GetBotName(),
+		Brokers:     s.kafkaReader.Config().Brokers,
+		MaxBytes:    10e6, // 10MB
+		Topic:       s.requestKey,
+		GroupID:     s.config.GetKafkaQueueGroupId(),
+		StartOffset: startOffset,
+	})
+	s.committer = newCommitter(s.config.GetKafkaQueueCommitBatch(), s.config.GetKafkaQueueCommitInterval(), s.kafkaReader.CommitMessages, func(err error) {
+		s.logger.Error("commit", err)
 	})
+
+	if s.config.GetKafkaQueueDeadLetter() {
+		s.deadLetterWriter = &kafka.Writer{
+			Addr:                   s.kafkaWriter.Addr,
+			AllowAutoTopicCreation: true,
+			Topic:                  fmt.Sprintf("%s-dead-letter", s.requestKey),
+		}
+		s.logger.Info("dead letter topic", s.deadLetterWriter.Topic)
+	}
 }
 func (s *Scheduler) FromSpider(spider pkg.Spider) pkg.Scheduler {
 	if s == nil {
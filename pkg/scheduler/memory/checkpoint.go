@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	request2 "github.com/lizongying/go-crawler/pkg/request"
+	"os"
+	"path/filepath"
+)
+
+// checkpoint is the state saved when a task is stopped before all requests are done.
+type checkpoint struct {
+	Spider   string            `json:"spider"`
+	Requests []json.RawMessage `json:"requests"`
+	Filter   []byte            `json:"filter,omitempty"`
+}
+
+// saveCheckpoint saves the pending and running requests and the filter to the checkpoint file.
+// The file is removed if there's nothing left to do.
+func (s *Scheduler) saveCheckpoint() (err error) {
+	if s.checkpoint == "" {
+		return
+	}
+
+	requests := s.requestQueue.Requests()
+	s.running.Range(func(key, _ any) bool {
+		requests = append(requests, key.(pkg.Request))
+		return true
+	})
+
+	if len(requests) == 0 {
+		err = os.Remove(s.checkpoint)
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	c := checkpoint{
+		Spider: s.spider.Name(),
+	}
+	for _, request := range requests {
+		var bs []byte
+		bs, err = request.Marshal()
+		if err != nil {
+			s.logger.Error(err)
+			continue
+		}
+		c.Requests = append(c.Requests, bs)
+	}
+
+	if filter, ok := s.spider.GetFilter().(pkg.FilterWithCheckpoint); ok {
+		var buf bytes.Buffer
+		if err = filter.SaveCheckpoint(&buf); err != nil {
+			s.logger.Error(err)
+			return
+		}
+		c.Filter = buf.Bytes()
+	}
+
+	bs, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+
+	// write to a temporary file first, so a crash won't leave a broken checkpoint
+	tmp := s.checkpoint + ".tmp"
+	if err = os.MkdirAll(filepath.Dir(s.checkpoint), 0755); err != nil {
+		return
+	}
+	if err = os.WriteFile(tmp, bs, 0644); err != nil {
+		return
+	}
+	if err = os.Rename(tmp, s.checkpoint); err != nil {
+		return
+	}
+
+	s.logger.Info("checkpoint saved:", s.checkpoint, "requests:", len(c.Requests))
+	return
+}
+
+// loadCheckpoint restores the filter and yields the requests saved in the checkpoint file.
+func (s *Scheduler) loadCheckpoint(ctx pkg.Context) (err error) {
+	if s.checkpoint == "" {
+		return
+	}
+
+	bs, err := os.ReadFile(s.checkpoint)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+
+	var c checkpoint
+	if err = json.Unmarshal(bs, &c); err != nil {
+		return
+	}
+	if c.Spider != s.spider.Name() {
+		err = errors.New("the checkpoint belongs to another spider: " + c.Spider)
+		return
+	}
+
+	if len(c.Filter) > 0 {
+		if filter, ok := s.spider.GetFilter().(pkg.FilterWithCheckpoint); ok {
+			if err = filter.LoadCheckpoint(bytes.NewReader(c.Filter)); err != nil {
+				return
+			}
+		} else {
+			s.logger.Warn("the filter doesn't support checkpoint, its state is not resumed")
+		}
+	}
+
+	for _, v := range c.Requests {
+		request := new(request2.Request)
+		if e := request.Unmarshal(v); e != nil {
+			s.logger.Error(e)
+			continue
+		}
+		if err = s.YieldRequest(ctx, request); err != nil {
+			return
+		}
+	}
+
+	s.logger.Info("checkpoint resumed:", s.checkpoint, "requests:", len(c.Requests))
+	return
+}
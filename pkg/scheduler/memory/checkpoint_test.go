@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lizongying/go-crawler/pkg"
+	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
+	request2 "github.com/lizongying/go-crawler/pkg/request"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testLogger struct {
+	pkg.Logger
+}
+
+func (testLogger) Info(...any)  {}
+func (testLogger) Error(...any) {}
+
+type testSpider struct {
+	pkg.Spider
+}
+
+func (testSpider) Name() string          { return "test" }
+func (testSpider) GetFilter() pkg.Filter { return nil }
+
+type testTask struct {
+	pkg.Task
+}
+
+func (testTask) RequestOut() {}
+
+func TestCheckpointInterrupted(t *testing.T) {
+	s := &Scheduler{
+		requestQueue: newRequestQueue(defaultRequestMax, false),
+		checkpoint:   filepath.Join(t.TempDir(), "checkpoint.json"),
+		spider:       testSpider{},
+		logger:       testLogger{},
+		task:         testTask{},
+	}
+
+	taskCtx, cancel := context.WithCancel(context.Background())
+	ctx := new(crawlerContext.Context).
+		WithTask(new(crawlerContext.Task).WithContext(taskCtx)).
+		WithRequest(new(crawlerContext.Request).WithStatus(pkg.RequestStatusRunning))
+	request := request2.Get().SetUrl("https://example.com/").WithContext(ctx)
+
+	// the task is stopped while the request is in flight
+	s.running.Store(request, struct{}{})
+	cancel()
+	s.requestFailed(ctx, request, context.Canceled)
+
+	if err := s.saveCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(s.checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c checkpoint
+	if err = json.Unmarshal(bs, &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Requests) != 1 {
+		t.Fatalf("got %d requests in the checkpoint, want 1", len(c.Requests))
+	}
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/queue"
+	"sort"
+	"sync"
+)
+
+// seqBits is the number of low bits of the heap key used for the sequence number,
+// the request priority takes the remaining high bits.
+const seqBits = 40
+
+var errRequestQueueFull = errors.New("exceeded the maximum number of requests")
+
+// requestQueue is a blocking queue of pending requests.
+// Like the redis scheduler, requests with a lower priority value are dequeued first,
+// requests with the same priority are dequeued in FIFO order.
+// If the priority queue is disabled, all requests are dequeued in FIFO order.
+type requestQueue struct {
+	mutex               sync.Mutex
+	queue               *queue.PriorityQueue
+	seq                 int64
+	notify              chan struct{}
+	enablePriorityQueue bool
+	maxSize             int
+}
+
+func (q *requestQueue) key(request pkg.Request) int64 {
+	q.seq = (q.seq + 1) & (1<<seqBits - 1)
+	if !q.enablePriorityQueue {
+		return q.seq
+	}
+	return int64(request.GetPriority())<<seqBits | q.seq
+}
+
+// Push adds a request to the queue and wakes up a waiting Pop.
+// It returns an error if the queue is full, the request isn't added.
+func (q *requestQueue) Push(request pkg.Request) (err error) {
+	q.mutex.Lock()
+	if q.queue.Len() >= q.maxSize {
+		q.mutex.Unlock()
+		err = errRequestQueueFull
+		return
+	}
+	q.queue.Push(queue.NewItem(request, q.key(request)))
+	q.mutex.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return
+}
+
+// TryPop returns the next request, ok is false if the queue is empty.
+func (q *requestQueue) TryPop() (request pkg.Request, ok bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.queue.Len() == 0 {
+		return
+	}
+
+	request, ok = q.queue.PopItem().Value().(pkg.Request)
+	return
+}
+
+// Notify is signaled after a request has been pushed.
+func (q *requestQueue) Notify() <-chan struct{} {
+	return q.notify
+}
+
+func (q *requestQueue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.queue.Len()
+}
+
+// Requests returns a snapshot of the pending requests in dequeue order, the queue is not changed.
+func (q *requestQueue) Requests() (requests []pkg.Request) {
+	q.mutex.Lock()
+	items, _ := q.queue.GetItemN(-1)
+	items = append([]*queue.Item{}, items...)
+	q.mutex.Unlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Priority() < items[j].Priority()
+	})
+	for _, v := range items {
+		requests = append(requests, v.Value().(pkg.Request))
+	}
+	return
+}
+
+func newRequestQueue(maxSize uint32, enablePriorityQueue bool) *requestQueue {
+	return &requestQueue{
+		queue:               queue.NewPriorityQueue(maxSize),
+		notify:              make(chan struct{}, 1),
+		enablePriorityQueue: enablePriorityQueue,
+		maxSize:             int(maxSize),
+	}
+}
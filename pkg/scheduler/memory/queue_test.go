@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"testing"
+)
+
+func TestRequestQueue(t *testing.T) {
+	q := newRequestQueue(10, true)
+	for _, v := range []struct {
+		url      string
+		priority uint8
+	}{
+		{"https://a/1", 2},
+		{"https://a/2", 1},
+		{"https://a/3", 2},
+		{"https://a/4", 0},
+		{"https://a/5", 1},
+	} {
+		q.Push(request.NewRequest().SetUrl(v.url).SetPriority(v.priority))
+	}
+
+	var urls []string
+	for {
+		r, ok := q.TryPop()
+		if !ok {
+			break
+		}
+		urls = append(urls, r.GetUrl())
+	}
+
+	want := []string{"https://a/4", "https://a/2", "https://a/5", "https://a/1", "https://a/3"}
+	if len(urls) != len(want) {
+		t.Fatalf("got %v, want %v", urls, want)
+	}
+	for i := range want {
+		if urls[i] != want[i] {
+			t.Fatalf("got %v, want %v", urls, want)
+		}
+	}
+}
+
+func TestRequestQueueFIFO(t *testing.T) {
+	q := newRequestQueue(10, false)
+	var requests []pkg.Request
+	for i, url := range []string{"https://a/1", "https://a/2", "https://a/3"} {
+		r := request.NewRequest().SetUrl(url).SetPriority(uint8(3 - i))
+		requests = append(requests, r)
+		q.Push(r)
+	}
+
+	for _, want := range requests {
+		r, ok := q.TryPop()
+		if !ok || r.GetUrl() != want.GetUrl() {
+			t.Fatalf("got %v, want %s", r, want.GetUrl())
+		}
+	}
+
+	if _, ok := q.TryPop(); ok {
+		t.Fatal("queue should be empty")
+	}
+}
+
+func TestRequestQueueFull(t *testing.T) {
+	q := newRequestQueue(2, true)
+	for i, url := range []string{"https://a/1", "https://a/2"} {
+		if err := q.Push(request.NewRequest().SetUrl(url).SetPriority(uint8(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the queued requests aren't replaced
+	if err := q.Push(request.NewRequest().SetUrl("https://a/3").SetPriority(9)); !errors.Is(err, errRequestQueueFull) {
+		t.Fatalf("got %v, want %v", err, errRequestQueueFull)
+	}
+	for _, want := range []string{"https://a/1", "https://a/2"} {
+		if r, ok := q.TryPop(); !ok || r.GetUrl() != want {
+			t.Fatalf("got %v, want %s", r, want)
+		}
+	}
+
+	if err := q.Push(request.NewRequest().SetUrl("https://a/3")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,43 +5,31 @@ import (
 	"errors"
 	"github.com/lizongying/go-crawler/pkg"
 	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
-	"golang.org/x/time/rate"
 	"net/http"
 	"reflect"
 	"time"
 )
 
 func (s *Scheduler) handleRequest(ctx pkg.Context) {
-	slot := "*"
-	value, _ := s.spider.RequestSlotLoad(slot)
-	requestSlot := value.(*rate.Limiter)
-
 out:
-	for request := range s.requestChan {
+	for {
 		select {
 		case <-ctx.GetTask().GetContext().Done():
 			s.logger.Error(ctx.GetTask().GetContext().Err())
 			break out
 		default:
-			ctx = request.GetContext()
-			slot = request.GetSlot()
-			if slot == "" {
-				slot = "*"
-			}
-			slotValue, ok := s.spider.RequestSlotLoad(slot)
+			request, ok := s.requestQueue.TryPop()
 			if !ok {
-				concurrency := uint8(1)
-				if request.GetConcurrency() != nil {
-					concurrency = *request.GetConcurrency()
+				select {
+				case <-ctx.GetTask().GetContext().Done():
+				case <-s.requestQueue.Notify():
 				}
-				if concurrency < 1 {
-					concurrency = 1
-				}
-				requestSlot = rate.NewLimiter(rate.Every(request.GetInterval()/time.Duration(concurrency)), int(concurrency))
-				s.spider.RequestSlotStore(slot, requestSlot)
+				continue
 			}
 
-			requestSlot = slotValue.(*rate.Limiter)
+			s.running.Store(request, struct{}{})
+			ctx = request.GetContext()
+			requestSlot := s.RequestSlot(request)
 
 			if err := requestSlot.Wait(ctx.GetTask().GetContext()); err != nil {
 				s.logger.Error(err, time.Now(), ctx)
@@ -53,11 +41,13 @@ out:
 				var err error
 
 				var response pkg.Response
-				response, err = s.Request(c, request.GetRequest())
+				response, err = s.RequestOnce(c, request.GetRequest())
+				if delay, ok := pkg.RetryDelay(err); ok {
+					s.retryRequest(request, delay)
+					return
+				}
 				if err != nil {
-					ctx.GetRequest().WithStatus(pkg.RequestStatusFailure).WithStopReason(err.Error())
-					s.crawler.GetSignal().RequestChanged(request)
-					s.task.RequestOut()
+					s.requestFailed(c, request, err)
 					return
 				}
 
@@ -69,6 +59,7 @@ out:
 							s.HandleError(ctx, response, err, request.GetErrBack())
 						}
 						s.task.MethodOut()
+						s.running.Delete(request)
 						s.task.RequestOut()
 					}()
 
@@ -90,13 +81,37 @@ out:
 	return
 }
 
-func (s *Scheduler) YieldRequest(ctx pkg.Context, request pkg.Request) (err error) {
-	if len(s.requestChan) >= defaultRequestMax {
-		err = errors.New("exceeded the maximum number of requests")
-		s.logger.Error(err)
+// requestFailed finishes the request which failed to download.
+// The request interrupted by the stop of the task is kept running, so it's saved in the checkpoint.
+func (s *Scheduler) requestFailed(ctx pkg.Context, request pkg.Request, err error) {
+	if ctx.GetTask().GetContext().Err() != nil {
+		s.logger.Info("request interrupted", request.GetUrl(), err)
+		s.task.RequestOut()
 		return
 	}
 
+	ctx.GetRequest().WithStatus(pkg.RequestStatusFailure).WithStopReason(err.Error())
+	s.crawler.GetSignal().RequestChanged(request)
+	s.running.Delete(request)
+	s.task.RequestOut()
+}
+
+// retryRequest re-enqueues the request after the delay.
+// It's kept running meanwhile, so it's still saved in the checkpoint.
+func (s *Scheduler) retryRequest(request pkg.Request, delay time.Duration) {
+	s.logger.Info("retry after", delay, request.GetUrl())
+	request.GetContext().GetRequest().WithStatus(pkg.RequestStatusPending)
+	s.crawler.GetSignal().RequestChanged(request)
+	time.AfterFunc(delay, func() {
+		s.running.Delete(request)
+		if err := s.requestQueue.Push(request); err != nil {
+			s.logger.Error(err)
+			s.requestFailed(request.GetContext(), request, err)
+		}
+	})
+}
+
+func (s *Scheduler) YieldRequest(ctx pkg.Context, request pkg.Request) (err error) {
 	requestCtx := ctx.GetRequest()
 	if requestCtx != nil {
 		// add referrer to request
@@ -127,8 +142,14 @@ func (s *Scheduler) YieldRequest(ctx pkg.Context, request pkg.Request) (err erro
 
 	request.WithContext(ctx)
 	s.crawler.GetSignal().RequestChanged(request)
-	s.requestChan <- request
 	ctx.GetTask().RequestIn()
+	if err = s.requestQueue.Push(request); err != nil {
+		s.logger.Error(err)
+		ctx.GetRequest().WithStatus(pkg.RequestStatusFailure).WithStopReason(err.Error())
+		s.crawler.GetSignal().RequestChanged(request)
+		ctx.GetTask().RequestOut()
+		return
+	}
 	return
 }
 
@@ -11,8 +11,10 @@ const defaultRequestMax = 1000 * 1000
 type Scheduler struct {
 	scheduler.UnimplementedScheduler
 
-	requestChan  chan pkg.Request
+	requestQueue *requestQueue
 	extraChanMap sync.Map
+	running      sync.Map // requests popped but not finished, saved to the checkpoint too
+	checkpoint   string
 
 	crawler pkg.Crawler
 	spider  pkg.Spider
@@ -27,6 +29,11 @@ func (s *Scheduler) StartScheduler(ctx pkg.Context) (err error) {
 	s.task = ctx.GetTask()
 	s.UnimplementedScheduler.SetTask(s.task)
 
+	if err = s.loadCheckpoint(ctx); err != nil {
+		s.logger.Error(err)
+		return
+	}
+
 	go s.HandleItem(ctx)
 
 	go s.handleRequest(ctx)
@@ -35,6 +42,9 @@ func (s *Scheduler) StartScheduler(ctx pkg.Context) (err error) {
 }
 
 func (s *Scheduler) StopScheduler(_ pkg.Context) (err error) {
+	if err = s.saveCheckpoint(); err != nil {
+		s.logger.Error(err)
+	}
 	return
 }
 func (s *Scheduler) FromSpider(spider pkg.Spider) pkg.Scheduler {
@@ -51,7 +61,8 @@ func (s *Scheduler) FromSpider(spider pkg.Spider) pkg.Scheduler {
 	s.UnimplementedScheduler.SetLogger(s.logger)
 	s.UnimplementedScheduler.Init()
 
-	s.requestChan = make(chan pkg.Request, defaultRequestMax)
+	s.requestQueue = newRequestQueue(defaultRequestMax, s.config.GetEnablePriorityQueue())
+	s.checkpoint = s.crawler.GetCheckpoint()
 
 	return s
 }
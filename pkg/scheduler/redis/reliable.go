@@ -0,0 +1,259 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// reclaimBatch is the max number of expired requests moved back in one script call.
+const reclaimBatch = 100
+
+// popPriorityScript moves the requests with the highest priority to the in-flight set,
+// and keeps their scores, so they can be reclaimed with the same priority.
+// KEYS: queue, in-flight, scores. ARGV: batch, deadline.
+var popPriorityScript = redis.NewScript(`
+local r = redis.call("ZRANGEBYSCORE", KEYS[1], 0, 2147483647, "LIMIT", 0, ARGV[1])
+for _, v in ipairs(r) do
+	redis.call("HSET", KEYS[3], v, redis.call("ZSCORE", KEYS[1], v))
+	redis.call("ZREM", KEYS[1], v)
+	redis.call("ZADD", KEYS[2], ARGV[2], v)
+end
+return r
+`)
+
+// popListScript moves the requests from the head of the list to the in-flight set.
+// KEYS: queue, in-flight. ARGV: batch, deadline.
+var popListScript = redis.NewScript(`
+local r = {}
+for i = 1, tonumber(ARGV[1]) do
+	local v = redis.call("LPOP", KEYS[1])
+	if not v then
+		break
+	end
+	redis.call("ZADD", KEYS[2], ARGV[2], v)
+	r[#r + 1] = v
+end
+return r
+`)
+
+// reclaimScript moves the requests whose lease has expired back to the queue,
+// the request reclaimed more than the max times is moved to the dead list instead,
+// and they're counted in the counter hash.
+// KEYS: queue, in-flight, scores, counter, reclaims, dead. ARGV: now, batch, priority, max reclaims.
+var reclaimScript = redis.NewScript(`
+local r = redis.call("ZRANGEBYSCORE", KEYS[2], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+local reclaimed, dead = 0, 0
+for _, v in ipairs(r) do
+	redis.call("ZREM", KEYS[2], v)
+	local score = redis.call("HGET", KEYS[3], v)
+	redis.call("HDEL", KEYS[3], v)
+	if redis.call("HINCRBY", KEYS[5], v, 1) > tonumber(ARGV[4]) then
+		redis.call("HDEL", KEYS[5], v)
+		redis.call("RPUSH", KEYS[6], v)
+		dead = dead + 1
+	else
+		if ARGV[3] == "1" then
+			redis.call("ZADD", KEYS[1], score or 0, v)
+		else
+			redis.call("LPUSH", KEYS[1], v)
+		end
+		reclaimed = reclaimed + 1
+	end
+end
+if reclaimed > 0 then
+	redis.call("HINCRBY", KEYS[4], "reclaimed", reclaimed)
+end
+if dead > 0 then
+	redis.call("HINCRBY", KEYS[4], "dead", dead)
+end
+return {#r, reclaimed, dead}
+`)
+
+// lease holds an in-flight request. It's extended in the background until
+// the request is acknowledged or released.
+type lease struct {
+	s      *Scheduler
+	member string
+	cancel context.CancelFunc
+}
+
+func (s *Scheduler) initReliable() {
+	s.inFlightKey = fmt.Sprintf("%s:inflight", s.requestKey)
+	s.scoreKey = fmt.Sprintf("%s:inflight:score", s.requestKey)
+	s.counterKey = fmt.Sprintf("%s:counter", s.requestKey)
+	s.reclaimsKey = fmt.Sprintf("%s:inflight:reclaims", s.requestKey)
+	s.deadKey = fmt.Sprintf("%s:dead", s.requestKey)
+	s.logger.Debug("in-flight key", s.inFlightKey)
+}
+
+// deadline returns the expiry of a lease taken now, in milliseconds.
+func (s *Scheduler) deadline() int64 {
+	return time.Now().Add(s.leaseTime).UnixMilli()
+}
+
+// popReliable moves at most s.batch requests to the in-flight set and returns them.
+func (s *Scheduler) popReliable(ctx context.Context) (requests []string, err error) {
+	if s.enablePriorityQueue {
+		requests, err = popPriorityScript.Run(ctx, s.redis, []string{s.requestKey, s.inFlightKey, s.scoreKey}, s.batch, s.deadline()).StringSlice()
+	} else {
+		requests, err = popListScript.Run(ctx, s.redis, []string{s.requestKey, s.inFlightKey}, s.batch, s.deadline()).StringSlice()
+	}
+	return
+}
+
+// hold takes the lease of the popped request, and extends it every third of the lease.
+func (s *Scheduler) hold(member string) *lease {
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &lease{
+		s:      s,
+		member: member,
+		cancel: cancel,
+	}
+	if stats, ok := s.stats.(pkg.StatsWithQueue); ok {
+		stats.IncRequestInFlight()
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.leaseTime / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c, cancel := context.WithTimeout(ctx, 10*time.Second)
+				err := s.redis.ZAddXX(c, s.inFlightKey, redis.Z{
+					Score:  float64(s.deadline()),
+					Member: member,
+				}).Err()
+				cancel()
+				if err != nil && ctx.Err() == nil {
+					s.logger.Warn("extend lease", err)
+				}
+			}
+		}
+	}()
+	return l
+}
+
+// release stops extending the lease, so the request is reclaimed once it expires.
+// It's safe to call release more than once.
+func (l *lease) release() {
+	if l == nil || l.cancel == nil {
+		return
+	}
+	l.cancel()
+	l.cancel = nil
+	if stats, ok := l.s.stats.(pkg.StatsWithQueue); ok {
+		stats.DecRequestInFlight()
+	}
+}
+
+// ack removes the request from the in-flight set, it won't be reclaimed anymore.
+// It's called once the request is handled, successfully or not, as a failed request would fail again.
+func (l *lease) ack() {
+	if l == nil || l.cancel == nil {
+		return
+	}
+	l.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := l.s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, l.s.inFlightKey, l.member)
+		pipe.HDel(ctx, l.s.scoreKey, l.member)
+		pipe.HDel(ctx, l.s.reclaimsKey, l.member)
+		return nil
+	}); err != nil {
+		l.s.logger.Error("ack", err)
+	}
+}
+
+// finish acknowledges the handled request, the request interrupted by the stop is released to be reclaimed.
+func (s *Scheduler) finish(ctx context.Context, l *lease) {
+	if ctx.Err() != nil {
+		l.release()
+		return
+	}
+	l.ack()
+}
+
+// reclaim moves the expired requests back to the queue, any node may reclaim them.
+// The requests reclaimed more than maxReclaims times are moved to the dead list.
+func (s *Scheduler) reclaim(ctx context.Context) (reclaimed int, dead int, err error) {
+	priority := "0"
+	if s.enablePriorityQueue {
+		priority = "1"
+	}
+	for {
+		var r []int64
+		r, err = reclaimScript.Run(ctx, s.redis, []string{s.requestKey, s.inFlightKey, s.scoreKey, s.counterKey, s.reclaimsKey, s.deadKey},
+			time.Now().UnixMilli(), reclaimBatch, priority, s.maxReclaims).Int64Slice()
+		if err != nil {
+			return
+		}
+		reclaimed += int(r[1])
+		dead += int(r[2])
+		if r[0] < reclaimBatch {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) handleReclaim(ctx pkg.Context) {
+	ticker := time.NewTicker(s.reclaimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.GetTask().GetContext().Done():
+			return
+		case <-ticker.C:
+			n, dead, err := s.reclaim(ctx.GetTask().GetContext())
+			if err != nil {
+				s.logger.Warn("reclaim", err)
+				continue
+			}
+			if dead > 0 {
+				s.logger.Warn("requests moved to the dead list", dead, s.deadKey)
+			}
+			if n == 0 {
+				continue
+			}
+			s.logger.Info("reclaimed requests", n)
+			// the reclaimed requests were counted by the node that lost them.
+			for i := 0; i < n; i++ {
+				s.task.RequestIn()
+			}
+			if stats, ok := s.stats.(pkg.StatsWithQueue); ok {
+				stats.IncRequestReclaimed(uint32(n))
+			}
+		}
+	}
+}
+
+// Dead returns the requests moved to the dead list after too many reclaims.
+func (s *Scheduler) Dead(ctx context.Context) ([]string, error) {
+	return s.redis.LRange(ctx, s.deadKey, 0, -1).Result()
+}
+
+// InFlight returns the number of the requests in flight on all nodes.
+func (s *Scheduler) InFlight(ctx context.Context) (int64, error) {
+	return s.redis.ZCard(ctx, s.inFlightKey).Result()
+}
+
+// Reclaimed returns the number of the requests reclaimed by all nodes.
+func (s *Scheduler) Reclaimed(ctx context.Context) (n int64, err error) {
+	r, err := s.redis.HGet(ctx, s.counterKey, "reclaimed").Result()
+	if err == redis.Nil {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	return strconv.ParseInt(r, 10, 64)
+}
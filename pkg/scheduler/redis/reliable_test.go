@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/lizongying/go-crawler/pkg/config"
+	"github.com/lizongying/go-crawler/pkg/loggers"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func newReliableScheduler(t *testing.T, priority bool) (*Scheduler, *miniredis.Miniredis) {
+	level := "error"
+	cfg := new(config.Config)
+	cfg.Log.Level = &level
+	logger, err := loggers.NewLogger(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := miniredis.RunT(t)
+	s := &Scheduler{
+		redis:               redis.NewClient(&redis.Options{Addr: m.Addr()}),
+		logger:              logger,
+		requestKey:          "test:request",
+		enablePriorityQueue: priority,
+		batch:               2,
+		reliable:            true,
+		leaseTime:           time.Minute,
+		maxReclaims:         cfg.GetRedisQueueMaxReclaims(),
+	}
+	s.initReliable()
+	t.Cleanup(func() {
+		_ = s.redis.Close()
+	})
+	return s, m
+}
+
+// expire moves the deadlines of the in-flight requests to the past, as the lease has expired.
+func expire(t *testing.T, s *Scheduler) {
+	ctx := context.Background()
+	members, err := s.redis.ZRange(ctx, s.inFlightKey, 0, -1).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range members {
+		s.redis.ZAdd(ctx, s.inFlightKey, redis.Z{Score: 0, Member: v})
+	}
+}
+
+func TestReliable_Ack(t *testing.T) {
+	s, _ := newReliableScheduler(t, false)
+	ctx := context.Background()
+	s.redis.RPush(ctx, s.requestKey, "a", "b", "c")
+
+	rs, err := s.popReliable(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 2 || rs[0] != "a" || rs[1] != "b" {
+		t.Fatalf("got %v", rs)
+	}
+	if n, _ := s.InFlight(ctx); n != 2 {
+		t.Errorf("got %d in flight, want 2", n)
+	}
+
+	// a handled request is acknowledged, an interrupted one is released
+	s.finish(ctx, s.hold(rs[0]))
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	s.finish(stopped, s.hold(rs[1]))
+
+	if n, _ := s.InFlight(ctx); n != 1 {
+		t.Errorf("got %d in flight, want the interrupted one", n)
+	}
+
+	expire(t, s)
+	reclaimed, dead, err := s.reclaim(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reclaimed != 1 || dead != 0 {
+		t.Errorf("got %d reclaimed and %d dead", reclaimed, dead)
+	}
+	if r, _ := s.redis.LRange(ctx, s.requestKey, 0, -1).Result(); len(r) != 2 || r[0] != "b" {
+		t.Errorf("got %v, want the reclaimed request at the head", r)
+	}
+	if n, _ := s.Reclaimed(ctx); n != 1 {
+		t.Errorf("got %d, want 1", n)
+	}
+}
+
+func TestReliable_Dead(t *testing.T) {
+	s, _ := newReliableScheduler(t, true)
+	ctx := context.Background()
+	s.redis.ZAdd(ctx, s.requestKey, redis.Z{Score: 5, Member: "a"})
+
+	for i := uint(0); i <= s.maxReclaims; i++ {
+		rs, err := s.popReliable(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rs) != 1 {
+			t.Fatalf("reclaim %d: got %v", i, rs)
+		}
+
+		// the node is lost without releasing the lease
+		expire(t, s)
+		reclaimed, dead, err := s.reclaim(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < s.maxReclaims {
+			if reclaimed != 1 || dead != 0 {
+				t.Fatalf("reclaim %d: got %d reclaimed and %d dead", i, reclaimed, dead)
+			}
+			// the priority is kept
+			if score, _ := s.redis.ZScore(ctx, s.requestKey, "a").Result(); score != 5 {
+				t.Errorf("got score %v, want 5", score)
+			}
+			continue
+		}
+		if reclaimed != 0 || dead != 1 {
+			t.Fatalf("reclaim %d: got %d reclaimed and %d dead", i, reclaimed, dead)
+		}
+	}
+
+	if n, _ := s.redis.ZCard(ctx, s.requestKey).Result(); n != 0 {
+		t.Errorf("got %d queued, want none", n)
+	}
+	if r, _ := s.Dead(ctx); len(r) != 1 || r[0] != "a" {
+		t.Errorf("got %v, want the request in the dead list", r)
+	}
+	if n, _ := s.redis.HLen(ctx, s.reclaimsKey).Result(); n != 0 {
+		t.Errorf("got %d reclaim counts, want none", n)
+	}
+}
@@ -9,16 +9,21 @@ import (
 	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
 	request2 "github.com/lizongying/go-crawler/pkg/request"
 	"github.com/redis/go-redis/v9"
-	"golang.org/x/time/rate"
 	"net/http"
 	"reflect"
 	"time"
 )
 
 func (s *Scheduler) handleRequest(ctx pkg.Context) {
-	slot := "*"
-	value, _ := s.spider.RequestSlotLoad(slot)
-	requestSlot := value.(*rate.Limiter)
+	taskCtx := ctx.GetTask().GetContext()
+	// the requests popped in a batch, their leases are held until they're handled
+	var pending []*lease
+	defer func() {
+		for _, l := range pending {
+			l.release()
+		}
+	}()
+
 out:
 	for {
 		select {
@@ -28,7 +33,29 @@ out:
 		default:
 			var req string
 			var err error
-			if s.enablePriorityQueue {
+			var l *lease
+			if s.reliable {
+				if len(pending) == 0 {
+					rs, e := s.popReliable(ctx.GetTask().GetContext())
+					if e != nil {
+						s.logger.Warn(e)
+						time.Sleep(1 * time.Second)
+						continue
+					}
+					if len(rs) == 0 {
+						err = errors.New("req is empty")
+						s.logger.Debug(err)
+						time.Sleep(1 * time.Second)
+						continue
+					}
+					for _, r := range rs {
+						pending = append(pending, s.hold(r))
+					}
+				}
+				l, pending = pending[0], pending[1:]
+				req = l.member
+				s.logger.Debug("req", req)
+			} else if s.enablePriorityQueue {
 				r, e := s.redis.Do(ctx.GetTask().GetContext(), "EVALSHA", s.requestKeySha, 1, s.requestKey, s.batch).Result()
 				if e != nil {
 					s.logger.Warn(e)
@@ -68,15 +95,19 @@ out:
 				req = r[1]
 			}
 
-			if err = s.redis.ZRem(ctx.GetTask().GetContext(), s.requestKey, req).Err(); err != nil {
-				s.logger.Warn(err)
-				continue
+			if !s.reliable && s.enablePriorityQueue {
+				if err = s.redis.ZRem(ctx.GetTask().GetContext(), s.requestKey, req).Err(); err != nil {
+					s.logger.Warn(err)
+					continue
+				}
 			}
 
 			s.logger.Debugf("request: %s", req)
 			request := new(request2.Request)
 			if err = request.Unmarshal([]byte(req)); err != nil {
 				s.logger.Warn(err)
+				// it can't be handled by any node
+				l.ack()
 				continue
 			}
 
@@ -86,24 +117,7 @@ out:
 				s.logger.Warn(err)
 				continue
 			}
-			slot = request.GetSlot()
-			if slot == "" {
-				slot = "*"
-			}
-			slotValue, ok := s.spider.RequestSlotLoad(slot)
-			if !ok {
-				concurrency := uint8(1)
-				if request.GetConcurrency() != nil {
-					concurrency = *request.GetConcurrency()
-				}
-				if concurrency < 1 {
-					concurrency = 1
-				}
-				requestSlot = rate.NewLimiter(rate.Every(request.GetInterval()/time.Duration(concurrency)), int(concurrency))
-				s.spider.RequestSlotStore(slot, requestSlot)
-			}
-
-			requestSlot = slotValue.(*rate.Limiter)
+			requestSlot := s.RequestSlot(request)
 
 			if err = requestSlot.Wait(ctx.GetTask().GetContext()); err != nil {
 				s.logger.Error(err)
@@ -112,10 +126,16 @@ out:
 			s.crawler.GetSignal().RequestChanged(request)
 			go func(c pkg.Context, request pkg.Request) {
 				var response pkg.Response
-				response, err = s.Request(c, request)
+				response, err = s.RequestOnce(c, request)
+				if delay, ok := pkg.RetryDelay(err); ok {
+					s.retryRequest(c, request, delay, l)
+					return
+				}
 				if err != nil {
 					ctx.GetRequest().WithStatus(pkg.RequestStatusFailure).WithStopReason(err.Error())
 					s.crawler.GetSignal().RequestChanged(request)
+					// the retries are used up
+					s.finish(taskCtx, l)
 					s.task.RequestOut()
 					return
 				}
@@ -127,6 +147,8 @@ out:
 							err = errors.New("panic")
 							s.HandleError(ctx, response, err, request.GetErrBack())
 						}
+						// the errback has run for the failed request
+						s.finish(taskCtx, l)
 						s.task.MethodOut()
 						s.task.RequestOut()
 					}()
@@ -147,6 +169,24 @@ out:
 	}
 }
 
+// retryRequest yields the request again after the delay.
+// The lease is acknowledged once the request is queued again, otherwise it's released to be reclaimed.
+func (s *Scheduler) retryRequest(ctx pkg.Context, request pkg.Request, delay time.Duration, l *lease) {
+	s.logger.Info("retry after", delay, request.GetUrl())
+	time.AfterFunc(delay, func() {
+		defer s.task.RequestOut()
+
+		if err := s.YieldRequest(ctx, request); err != nil {
+			s.logger.Error(err)
+			ctx.GetRequest().WithStatus(pkg.RequestStatusFailure).WithStopReason(err.Error())
+			s.crawler.GetSignal().RequestChanged(request)
+			l.release()
+			return
+		}
+		l.ack()
+	})
+}
+
 func (s *Scheduler) YieldRequest(ctx pkg.Context, request pkg.Request) (err error) {
 	c := context.Background()
 	c, cancel := context.WithTimeout(c, 10*time.Second)
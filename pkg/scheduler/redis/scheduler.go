@@ -6,6 +6,7 @@ import (
 	"github.com/lizongying/go-crawler/pkg"
 	"github.com/lizongying/go-crawler/pkg/scheduler"
 	"github.com/redis/go-redis/v9"
+	"time"
 )
 
 const defaultRequestMax = 1000 * 1000
@@ -17,16 +18,27 @@ type Scheduler struct {
 	redis         *redis.Client
 	requestKey    string
 	requestKeySha string
+	// only the reliable queue
+	inFlightKey string
+	scoreKey    string
+	counterKey  string
+	reclaimsKey string
+	deadKey     string
 
 	crawler pkg.Crawler
 	spider  pkg.Spider
 	config  pkg.Config
 	logger  pkg.Logger
 	task    pkg.Task
+	stats   pkg.Stats
 
 	env                 string
 	enablePriorityQueue bool
 	batch               uint8
+	reliable            bool
+	leaseTime           time.Duration
+	reclaimInterval     time.Duration
+	maxReclaims         uint
 }
 
 func (s *Scheduler) StartScheduler(ctx pkg.Context) (err error) {
@@ -38,11 +50,16 @@ func (s *Scheduler) StartScheduler(ctx pkg.Context) (err error) {
 
 	s.task = ctx.GetTask()
 	s.UnimplementedScheduler.SetTask(s.task)
+	s.stats = ctx.GetTask().GetStats()
 
 	s.initScheduler(ctx)
 
 	go s.HandleItem(ctx)
 
+	if s.reliable {
+		go s.handleReclaim(ctx)
+	}
+
 	go s.handleRequest(ctx)
 	return
 }
@@ -76,8 +93,15 @@ return r
 	}
 
 	s.logger.Debug("request key", s.requestKey)
+	if s.reliable {
+		s.initReliable()
+	}
 	if s.env == "dev" {
-		err := s.redis.Del(ctx.GetTask().GetContext(), s.requestKey).Err()
+		keys := []string{s.requestKey}
+		if s.reliable {
+			keys = append(keys, s.inFlightKey, s.scoreKey, s.counterKey, s.reclaimsKey, s.deadKey)
+		}
+		err := s.redis.Del(ctx.GetTask().GetContext(), keys...).Err()
 		if err != nil {
 			s.logger.Error(err)
 			return
@@ -104,6 +128,10 @@ func (s *Scheduler) FromSpider(spider pkg.Spider) pkg.Scheduler {
 	s.env = s.config.GetEnv()
 	s.enablePriorityQueue = s.config.GetEnablePriorityQueue()
 	s.batch = 1
+	s.reliable = s.config.GetRedisQueueReliable()
+	s.leaseTime = s.config.GetRedisQueueLease()
+	s.reclaimInterval = s.config.GetRedisQueueReclaimInterval()
+	s.maxReclaims = s.config.GetRedisQueueMaxReclaims()
 
 	return s
 }
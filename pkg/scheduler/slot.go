@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
+	"net"
+	"net/url"
+	"time"
+)
+
+// RequestSlot returns the limiter of the request slot, the slot is derived from the url if it's not set.
+// The limiter is created on first use, by the slot options in the config, or the interval and concurrency of the request.
+// The derived slots without options are limited as the "*" slot.
+func (s *UnimplementedScheduler) RequestSlot(request pkg.Request) *rate.Limiter {
+	slot := request.GetSlot()
+	derived := false
+	if slot == "" {
+		slot = slotOf(request.GetURL(), s.spider.SlotType())
+		if slot != "*" {
+			request.SetSlot(slot)
+			derived = true
+		}
+	}
+
+	if value, ok := s.spider.RequestSlotLoad(slot); ok {
+		return value.(*rate.Limiter)
+	}
+
+	var limiter *rate.Limiter
+	if options, ok := s.crawler.GetConfig().GetRequestSlots()[slot]; ok {
+		limiter = newLimiter(options.Interval, options.Concurrency)
+	} else if value, ok := s.spider.RequestSlotLoad("*"); ok && derived && request.GetInterval() == 0 && request.GetConcurrency() == nil {
+		defaultSlot := value.(*rate.Limiter)
+		limiter = rate.NewLimiter(defaultSlot.Limit(), defaultSlot.Burst())
+	} else {
+		concurrency := uint8(1)
+		if request.GetConcurrency() != nil {
+			concurrency = *request.GetConcurrency()
+		}
+		limiter = newLimiter(request.GetInterval(), concurrency)
+	}
+	// another request may have created the limiter of the slot meanwhile
+	value, _ := s.spider.RequestSlotLoadOrStore(slot, limiter)
+	return value.(*rate.Limiter)
+}
+
+func newLimiter(interval time.Duration, concurrency uint8) *rate.Limiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return rate.NewLimiter(rate.Every(interval/time.Duration(concurrency)), int(concurrency))
+}
+
+// slotOf returns the slot of the url by the slot type, it's "*" if the slot isn't derived.
+func slotOf(u *url.URL, slotType pkg.SlotType) string {
+	if u == nil || u.Hostname() == "" {
+		return "*"
+	}
+
+	host := u.Hostname()
+	switch slotType {
+	case pkg.SlotTypeHost:
+		return host
+	case pkg.SlotTypeDomain:
+		if net.ParseIP(host) != nil {
+			return host
+		}
+		domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			return host
+		}
+		return domain
+	default:
+		return "*"
+	}
+}
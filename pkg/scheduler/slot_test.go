@@ -0,0 +1,27 @@
+package scheduler
+
+import (
+	"github.com/lizongying/go-crawler/pkg"
+	"net/url"
+	"testing"
+)
+
+func TestSlotOf(t *testing.T) {
+	for _, v := range []struct {
+		url      string
+		slotType pkg.SlotType
+		slot     string
+	}{
+		{"https://a.example.com/b", pkg.SlotTypeNone, "*"},
+		{"https://a.example.com:8080/b", pkg.SlotTypeHost, "a.example.com"},
+		{"https://a.example.com/b", pkg.SlotTypeDomain, "example.com"},
+		{"https://a.b.example.co.uk/c", pkg.SlotTypeDomain, "example.co.uk"},
+		{"http://127.0.0.1:8080/", pkg.SlotTypeDomain, "127.0.0.1"},
+		{"http://localhost/", pkg.SlotTypeDomain, "localhost"},
+	} {
+		u, _ := url.Parse(v.url)
+		if slot := slotOf(u, v.slotType); slot != v.slot {
+			t.Errorf("%s %q: got %s, want %s", v.url, v.slotType, slot, v.slot)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/lizongying/go-crawler/pkg"
 	crawlerContext "github.com/lizongying/go-crawler/pkg/context"
 	"reflect"
+	"time"
 )
 
 const defaultChanItemMax = 1000 * 1000
@@ -148,7 +149,7 @@ func (s *UnimplementedScheduler) HandleError(ctx pkg.Context, response pkg.Respo
 	s.spider.ErrBack(errBackName)(ctx, response, err)
 	ctx.GetTask().IncRequestError()
 }
-func (s *UnimplementedScheduler) SyncRequest(ctx pkg.Context, request pkg.Request) (response pkg.Response, err error) {
+func (s *UnimplementedScheduler) download(ctx pkg.Context, request pkg.Request) (response pkg.Response, err error) {
 	if request == nil {
 		err = errors.New("nil request")
 		return
@@ -164,6 +165,10 @@ func (s *UnimplementedScheduler) SyncRequest(ctx pkg.Context, request pkg.Reques
 			return
 		}
 
+		if errors.Is(err, pkg.ErrNeedRetry) {
+			return
+		}
+
 		s.HandleError(ctx, response, err, request.GetErrBack())
 		return
 	}
@@ -171,9 +176,37 @@ func (s *UnimplementedScheduler) SyncRequest(ctx pkg.Context, request pkg.Reques
 	s.logger.Debugf("request %+v", request)
 	return
 }
+
+// SyncRequest downloads the request, waiting for the retries in place.
+func (s *UnimplementedScheduler) SyncRequest(ctx pkg.Context, request pkg.Request) (response pkg.Response, err error) {
+	for {
+		response, err = s.download(ctx, request)
+		delay, ok := pkg.RetryDelay(err)
+		if !ok {
+			return
+		}
+
+		s.logger.Info("retry after", delay, request.GetUrl())
+		select {
+		case <-ctx.GetTask().GetContext().Done():
+			err = ctx.GetTask().GetContext().Err()
+			return
+		case <-time.After(delay):
+		}
+	}
+}
 func (s *UnimplementedScheduler) Request(ctx pkg.Context, request pkg.Request) (response pkg.Response, err error) {
 	ctx.GetTask().RequestIn()
 	response, err = s.SyncRequest(ctx, request)
 	s.task.RequestOut()
 	return
 }
+
+// RequestOnce downloads the request once.
+// The retry error is returned, then the scheduler re-enqueues the request after the delay.
+func (s *UnimplementedScheduler) RequestOnce(ctx pkg.Context, request pkg.Request) (response pkg.Response, err error) {
+	ctx.GetTask().RequestIn()
+	response, err = s.download(ctx, request)
+	s.task.RequestOut()
+	return
+}
@@ -0,0 +1,92 @@
+package pkg
+
+// SessionAny binds a request to the next valid session of the pool, the sessions are rotated.
+const SessionAny = "*"
+
+// Session is an identity of the spider, e.g. an account.
+// The requests bound to a session by request.SetSession use its cookie jar, headers, proxy, device and slot.
+type Session struct {
+	Name        string            `json:"name"`
+	Username    string            `json:"username,omitempty"`
+	Password    string            `json:"password,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Proxy       string            `json:"proxy,omitempty"`
+	UserAgent   string            `json:"user_agent,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"` // the tls fingerprint, e.g. chrome
+	Slot        string            `json:"slot,omitempty"`        // the slot of the requests, the derived slot if empty
+}
+
+// Apply sets the cookie jar, headers, proxy, device and slot of the session to the request.
+// It can be used by the login requests, which aren't bound to the session.
+// The session applied before should be cleared by Clear first, Sessions.Bind does so.
+func (s *Session) Apply(request Request) {
+	request.SetCookieJar(s.Name)
+	for k, v := range s.Headers {
+		request.SetHeader(k, v)
+	}
+	if s.Proxy != "" {
+		request.SetProxy(s.Proxy)
+	}
+	if s.UserAgent != "" {
+		request.SetHeader("User-Agent", s.UserAgent)
+	}
+	if s.Fingerprint != "" {
+		request.SetFingerprint(s.Fingerprint)
+	}
+	if s.Slot != "" {
+		request.SetSlot(s.Slot)
+	}
+}
+
+// Clear removes the cookie jar, headers, proxy, device and slot of the session from the request,
+// the ones changed since the session was applied are kept.
+func (s *Session) Clear(request Request) {
+	if request.GetCookieJar() == s.Name {
+		request.SetCookieJar("")
+	}
+	for k, v := range s.Headers {
+		if request.GetHeader(k) == v {
+			request.Headers().Del(k)
+		}
+	}
+	if s.Proxy != "" && request.GetProxy() != nil && request.GetProxy().String() == s.Proxy {
+		request.SetProxy("")
+	}
+	if s.UserAgent != "" && request.GetHeader("User-Agent") == s.UserAgent {
+		request.Headers().Del("User-Agent")
+	}
+	if s.Fingerprint != "" && request.GetFingerprint() == s.Fingerprint {
+		request.SetFingerprint("")
+	}
+	if s.Slot != "" && request.GetSlot() == s.Slot {
+		request.SetSlot("")
+	}
+}
+
+// Sessions is the pool of the sessions of a spider.
+type Sessions interface {
+	Add(...*Session)
+	Get(name string) (*Session, bool)
+	// Next returns the next valid session, ErrNoSession if there isn't any.
+	Next() (*Session, error)
+	// Bind binds the request to its session and applies the session, SessionAny is bound to the next valid session.
+	Bind(Request) (*Session, error)
+	// Invalidate marks the session invalid, it's logged in again before the next use if possible.
+	Invalidate(name string)
+	// Login logs in the session if it isn't, the login is serialized per session.
+	// If login is nil, it returns ErrSessionInvalid for an invalid session.
+	Login(name string, login func(*Session) error) error
+	Len() int
+}
+
+// SpiderWithSessionLogin logs in a session, e.g. posts the username and password with the cookie jar of the session.
+// It's called before the first request of the session, and after the session is marked invalid.
+type SpiderWithSessionLogin interface {
+	SessionLogin(Context, *Session) error
+}
+
+// SpiderWithSessionCheck checks whether the session of the response is still valid, e.g. it isn't a login page.
+// The invalid session is logged in again, or the request is retried with another session.
+type SpiderWithSessionCheck interface {
+	SessionCheck(Context, Response) bool
+}
@@ -0,0 +1,151 @@
+package sessions
+
+import (
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"sync"
+	"sync/atomic"
+)
+
+type state struct {
+	session  *pkg.Session
+	invalid  atomic.Bool
+	loggedIn bool       // guarded by the mutex
+	mutex    sync.Mutex // serializes the login
+}
+
+// Pool keeps the sessions of a spider, the valid ones are rotated in the added order.
+type Pool struct {
+	mutex  sync.RWMutex
+	states map[string]*state
+	names  []string
+	index  int
+}
+
+func (p *Pool) Add(sessions ...*pkg.Session) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, session := range sessions {
+		if session == nil || session.Name == "" {
+			continue
+		}
+		if _, ok := p.states[session.Name]; !ok {
+			p.names = append(p.names, session.Name)
+		}
+		p.states[session.Name] = &state{session: session}
+	}
+}
+
+func (p *Pool) Get(name string) (session *pkg.Session, ok bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	st, ok := p.states[name]
+	if !ok {
+		return
+	}
+	session = st.session
+	return
+}
+
+func (p *Pool) Next() (session *pkg.Session, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i := 0; i < len(p.names); i++ {
+		st := p.states[p.names[p.index%len(p.names)]]
+		p.index = (p.index + 1) % len(p.names)
+		if !st.invalid.Load() {
+			session = st.session
+			return
+		}
+	}
+
+	err = pkg.ErrNoSession
+	return
+}
+
+func (p *Pool) Bind(request pkg.Request) (session *pkg.Session, err error) {
+	name := request.GetSession()
+	if name == pkg.SessionAny {
+		session, err = p.Next()
+		if err != nil {
+			return
+		}
+	} else {
+		var ok bool
+		session, ok = p.Get(name)
+		if !ok {
+			err = fmt.Errorf("session not found: %s", name)
+			return
+		}
+	}
+
+	// the request rebound to another session, e.g. after its session is unavailable, drops the previous one
+	if previous, ok := p.Get(request.GetCookieJar()); ok && previous != session {
+		previous.Clear(request)
+	}
+	request.SetSession(session.Name)
+	session.Apply(request)
+	return
+}
+
+func (p *Pool) Invalidate(name string) {
+	p.mutex.RLock()
+	st, ok := p.states[name]
+	p.mutex.RUnlock()
+	if !ok {
+		return
+	}
+
+	st.invalid.Store(true)
+}
+
+func (p *Pool) Login(name string, login func(*pkg.Session) error) (err error) {
+	p.mutex.RLock()
+	st, ok := p.states[name]
+	p.mutex.RUnlock()
+	if !ok {
+		err = fmt.Errorf("session not found: %s", name)
+		return
+	}
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if login == nil {
+		if st.invalid.Load() {
+			err = pkg.ErrSessionInvalid
+		}
+		return
+	}
+
+	// logged in by another request
+	if st.loggedIn && !st.invalid.Load() {
+		return
+	}
+
+	if err = login(st.session); err != nil {
+		st.invalid.Store(true)
+		st.loggedIn = false
+		return
+	}
+	st.invalid.Store(false)
+	st.loggedIn = true
+	return
+}
+
+func (p *Pool) Len() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return len(p.names)
+}
+
+func NewPool() (pool *Pool) {
+	pool = &Pool{
+		states: make(map[string]*state),
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package sessions
+
+import (
+	"errors"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	pool := NewPool()
+	pool.Add(
+		&pkg.Session{Name: "a", Headers: map[string]string{"X-Account": "a"}, Slot: "account-a"},
+		&pkg.Session{Name: "b", Proxy: "http://127.0.0.1:8080"},
+	)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		session, err := pool.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, session.Name)
+	}
+	if got := names[0] + names[1] + names[2]; got != "aba" {
+		t.Errorf("Next() rotated %q, want %q", got, "aba")
+	}
+
+	req := request.NewRequest().SetSession("a")
+	if _, err := pool.Bind(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.GetCookieJar() != "a" || req.GetHeader("X-Account") != "a" || req.GetSlot() != "account-a" {
+		t.Errorf("Bind() didn't apply the session: jar %q, slot %q", req.GetCookieJar(), req.GetSlot())
+	}
+
+	// rebound to another session, the previous one is dropped
+	req.SetSession("b")
+	if _, err := pool.Bind(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.GetCookieJar() != "b" || req.GetHeader("X-Account") != "" || req.GetSlot() != "" || req.GetProxy() == nil {
+		t.Errorf("Bind() kept the previous session: jar %q, slot %q", req.GetCookieJar(), req.GetSlot())
+	}
+
+	// the invalid session isn't rotated, and can't be used without login
+	pool.Invalidate("a")
+	if session, _ := pool.Next(); session.Name != "b" {
+		t.Errorf("Next() = %s, want b", session.Name)
+	}
+	if err := pool.Login("a", nil); !errors.Is(err, pkg.ErrSessionInvalid) {
+		t.Errorf("Login() = %v, want ErrSessionInvalid", err)
+	}
+
+	// logged in once, until it's invalid again
+	logins := 0
+	login := func(*pkg.Session) error {
+		logins++
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := pool.Login("a", login); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.Invalidate("a")
+	if err := pool.Login("a", login); err != nil {
+		t.Fatal(err)
+	}
+	if logins != 2 {
+		t.Errorf("logins = %d, want 2", logins)
+	}
+
+	pool.Invalidate("a")
+	_ = pool.Login("a", func(*pkg.Session) error { return errors.New("wrong password") })
+	pool.Invalidate("b")
+	if _, err := pool.Bind(request.NewRequest().SetSession(pkg.SessionAny)); !errors.Is(err, pkg.ErrNoSession) {
+		t.Errorf("Bind() = %v, want ErrNoSession", err)
+	}
+}
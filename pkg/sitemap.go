@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"regexp"
+	"time"
+)
+
+const DefaultSitemapMaxDepth = uint8(10)
+
+type SitemapOptions struct {
+	LastModAfter   time.Time      // only yield urls modified after it, urls without lastmod are always yielded
+	UrlRegexp      *regexp.Regexp // only yield urls matching it
+	SitemapRegexp  *regexp.Regexp // only follow nested sitemaps matching it
+	MaxDepth       uint8          // max depth of nested sitemaps
+	RequestOptions []RequestOption
+}
+
+type SitemapOption func(*SitemapOptions)
+
+func WithSitemapLastModAfter(lastModAfter time.Time) SitemapOption {
+	return func(options *SitemapOptions) {
+		options.LastModAfter = lastModAfter
+	}
+}
+func WithSitemapUrlRegexp(urlRegexp *regexp.Regexp) SitemapOption {
+	return func(options *SitemapOptions) {
+		options.UrlRegexp = urlRegexp
+	}
+}
+func WithSitemapSitemapRegexp(sitemapRegexp *regexp.Regexp) SitemapOption {
+	return func(options *SitemapOptions) {
+		options.SitemapRegexp = sitemapRegexp
+	}
+}
+func WithSitemapMaxDepth(maxDepth uint8) SitemapOption {
+	return func(options *SitemapOptions) {
+		options.MaxDepth = maxDepth
+	}
+}
+
+// WithSitemapRequestOptions the options are applied to every request yielded.
+func WithSitemapRequestOptions(requestOptions ...RequestOption) SitemapOption {
+	return func(options *SitemapOptions) {
+		options.RequestOptions = append(options.RequestOptions, requestOptions...)
+	}
+}
@@ -0,0 +1,149 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"golang.org/x/net/html/charset"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxSize is the max uncompressed size of a sitemap, as defined by sitemaps.org.
+const MaxSize = 50 * 1024 * 1024
+
+var ErrTooLarge = errors.New("sitemap too large")
+
+// lastModLayouts W3C Datetime, https://www.w3.org/TR/NOTE-datetime
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+type Url struct {
+	Loc     string
+	LastMod time.Time // zero if absent or invalid
+}
+
+// Sitemap is the result of parsing a sitemap.
+// Urls are the pages, Sitemaps are the nested sitemaps from a sitemap index or robots.txt.
+type Sitemap struct {
+	Urls     []Url
+	Sitemaps []Url
+}
+
+type xmlUrl struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+type xmlSitemap struct {
+	XMLName  xml.Name
+	Urls     []xmlUrl `xml:"url"`
+	Sitemaps []xmlUrl `xml:"sitemap"`
+}
+
+func parseLastMod(lastMod string) (t time.Time) {
+	lastMod = strings.TrimSpace(lastMod)
+	if lastMod == "" {
+		return
+	}
+	for _, layout := range lastModLayouts {
+		if v, err := time.Parse(layout, lastMod); err == nil {
+			return v
+		}
+	}
+	return
+}
+
+func gunzip(body []byte) (bs []byte, err error) {
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	bs, err = io.ReadAll(io.LimitReader(reader, MaxSize+1))
+	if err != nil {
+		return
+	}
+	if len(bs) > MaxSize {
+		err = ErrTooLarge
+	}
+	return
+}
+
+func parseXml(body []byte) (sitemap *Sitemap, err error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+
+	var s xmlSitemap
+	if err = decoder.Decode(&s); err != nil {
+		return
+	}
+
+	sitemap = new(Sitemap)
+	for _, v := range s.Urls {
+		if loc := strings.TrimSpace(v.Loc); loc != "" {
+			sitemap.Urls = append(sitemap.Urls, Url{Loc: loc, LastMod: parseLastMod(v.LastMod)})
+		}
+	}
+	for _, v := range s.Sitemaps {
+		if loc := strings.TrimSpace(v.Loc); loc != "" {
+			sitemap.Sitemaps = append(sitemap.Sitemaps, Url{Loc: loc, LastMod: parseLastMod(v.LastMod)})
+		}
+	}
+	return
+}
+
+// parseText parses a text sitemap (one url per line) or a robots.txt (Sitemap: lines).
+func parseText(body []byte) (sitemap *Sitemap, err error) {
+	sitemap = new(Sitemap)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 8 && strings.EqualFold(line[:8], "sitemap:") {
+			if loc := strings.TrimSpace(line[8:]); loc != "" {
+				sitemap.Sitemaps = append(sitemap.Sitemaps, Url{Loc: loc})
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			sitemap.Urls = append(sitemap.Urls, Url{Loc: line})
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// Parse parses a sitemap, a sitemap index, a text sitemap or a robots.txt, gzip compressed or not.
+func Parse(body []byte) (sitemap *Sitemap, err error) {
+	if len(body) > 1 && body[0] == 0x1f && body[1] == 0x8b {
+		body, err = gunzip(body)
+		if err != nil {
+			return
+		}
+	}
+	if len(body) > MaxSize {
+		err = ErrTooLarge
+		return
+	}
+
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		return parseXml(trimmed)
+	}
+
+	return parseText(trimmed)
+}
@@ -0,0 +1,91 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	body := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/a</loc>
+    <lastmod>2023-10-01</lastmod>
+  </url>
+  <url>
+    <loc> https://example.com/b </loc>
+    <lastmod>2023-10-02T08:00:00+08:00</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/c</loc>
+  </url>
+</urlset>`)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(body)
+	_ = w.Close()
+
+	for _, b := range [][]byte{body, buf.Bytes()} {
+		s, err := Parse(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s.Urls) != 3 || len(s.Sitemaps) != 0 {
+			t.Fatalf("%+v", s)
+		}
+		if s.Urls[1].Loc != "https://example.com/b" {
+			t.Fatal(s.Urls[1].Loc)
+		}
+		if !s.Urls[0].LastMod.Equal(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatal(s.Urls[0].LastMod)
+		}
+		if !s.Urls[1].LastMod.Equal(time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Fatal(s.Urls[1].LastMod)
+		}
+		if !s.Urls[2].LastMod.IsZero() {
+			t.Fatal(s.Urls[2].LastMod)
+		}
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	s, err := Parse([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://example.com/sitemap1.xml.gz</loc>
+    <lastmod>2004-10-01T18:23:17+00:00</lastmod>
+  </sitemap>
+  <sitemap>
+    <loc>https://example.com/sitemap2.xml</loc>
+  </sitemap>
+</sitemapindex>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Urls) != 0 || len(s.Sitemaps) != 2 {
+		t.Fatalf("%+v", s)
+	}
+	if s.Sitemaps[0].Loc != "https://example.com/sitemap1.xml.gz" || s.Sitemaps[0].LastMod.IsZero() {
+		t.Fatalf("%+v", s.Sitemaps[0])
+	}
+}
+
+func TestParseRobotsTxt(t *testing.T) {
+	s, err := Parse([]byte(`User-agent: *
+Disallow: /private
+sitemap: https://example.com/sitemap.xml
+Sitemap: https://example.com/news.xml
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Urls) != 0 || len(s.Sitemaps) != 2 {
+		t.Fatalf("%+v", s)
+	}
+	if s.Sitemaps[1].Loc != "https://example.com/news.xml" {
+		t.Fatal(s.Sitemaps[1].Loc)
+	}
+}
@@ -0,0 +1,17 @@
+package pkg
+
+import "time"
+
+type SlotType string
+
+const (
+	SlotTypeNone   SlotType = ""       // the requests without slot share the "*" slot
+	SlotTypeHost   SlotType = "host"   // the slot is the host of the request
+	SlotTypeDomain SlotType = "domain" // the slot is the registrable domain of the request, e.g. example.co.uk
+)
+
+// SlotOptions overrides the rate of a request slot.
+type SlotOptions struct {
+	Interval    time.Duration
+	Concurrency uint8
+}
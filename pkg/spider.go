@@ -60,11 +60,14 @@ type Spider interface {
 	NewRequest(Context, ...RequestOption) error
 	MustYieldRequest(Context, Request)
 	MustNewRequest(Context, ...RequestOption)
+	YieldSitemap(Context, CallBack, []string, ...SitemapOption) error
 	YieldExtra(Context, any) error
 	MustYieldExtra(Context, any)
 	GetExtra(Context, any) error
 	MustGetExtra(Context, any)
 	SetRequestRate(slot string, interval time.Duration, concurrency int)
+	GetSitemaps() []string
+	AddSitemaps(...string)
 	AddMockServerRoutes(...Route)
 
 	GetCrawler() Crawler
@@ -77,6 +80,14 @@ type Spider interface {
 
 	RequestSlotLoad(slot string) (value any, ok bool)
 	RequestSlotStore(slot string, value any)
+	RequestSlotLoadOrStore(slot string, value any) (actual any, loaded bool)
+	GetCookieJar(name string) CookieJar // nil if the jar isn't created, "" is CookieJarDefault
+	SetCookieJar(name string, jar CookieJar) Spider
+	LoadOrStoreCookieJar(name string, jar CookieJar) CookieJar // the existing jar if it's created, or jar
+	CookieJars() map[string]CookieJar
+	GetSessions() Sessions
+	SlotType() SlotType
+	SetSlotType(SlotType) Spider
 
 	RerunJob(ctx context.Context, jobId string) (err error)
 	KillJob(ctx context.Context, jobId string) (err error)
@@ -165,6 +176,29 @@ func WithRobotsTxtMiddleware() SpiderOption {
 		spider.GetMiddlewares().WithRobotsTxtMiddleware()
 	}
 }
+func WithSessionMiddleware() SpiderOption {
+	return func(spider Spider) {
+		spider.GetMiddlewares().WithSessionMiddleware()
+	}
+}
+
+// WithSessions adds the sessions to the pool of the spider, and enables the session middleware.
+func WithSessions(sessions ...*Session) SpiderOption {
+	return func(spider Spider) {
+		spider.GetSessions().Add(sessions...)
+		spider.GetMiddlewares().WithSessionMiddleware()
+	}
+}
+func WithAutoThrottleMiddleware() SpiderOption {
+	return func(spider Spider) {
+		spider.GetMiddlewares().WithAutoThrottleMiddleware()
+	}
+}
+func WithHttpCacheMiddleware() SpiderOption {
+	return func(spider Spider) {
+		spider.GetMiddlewares().WithHttpCacheMiddleware()
+	}
+}
 func WithFilterMiddleware() SpiderOption {
 	return func(spider Spider) {
 		spider.GetMiddlewares().WithFilterMiddleware()
@@ -10,6 +10,7 @@ import (
 	"github.com/lizongying/go-crawler/pkg/exporter"
 	"github.com/lizongying/go-crawler/pkg/filters"
 	"github.com/lizongying/go-crawler/pkg/request"
+	"github.com/lizongying/go-crawler/pkg/sessions"
 	"github.com/lizongying/go-crawler/pkg/utils"
 	"golang.org/x/time/rate"
 	"log"
@@ -66,6 +67,17 @@ type BaseSpider struct {
 	pkg.Exporter
 
 	requestSlots sync.Map
+	// the slot of the requests without slot, they share the "*" slot by default
+	slotType pkg.SlotType
+
+	// the cookie jars by name, they're created by the cookie middleware
+	cookieJars sync.Map
+
+	sessions pkg.Sessions
+
+	// sitemaps found in robots.txt
+	sitemaps      []string
+	sitemapsMutex sync.RWMutex
 }
 
 func (s *BaseSpider) GetDownloader() pkg.Downloader {
@@ -285,6 +297,10 @@ func (s *BaseSpider) Request(ctx pkg.Context, request pkg.Request) (response pkg
 	return ctx.GetTask().Request(ctx, request)
 }
 func (s *BaseSpider) YieldRequest(ctx pkg.Context, request pkg.Request) (err error) {
+	if err = s.bindSession(request); err != nil {
+		s.logger.Error(err)
+		return
+	}
 	return ctx.GetTask().YieldRequest(ctx, request)
 }
 func (s *BaseSpider) MustYieldRequest(ctx pkg.Context, request pkg.Request) {
@@ -297,8 +313,21 @@ func (s *BaseSpider) NewRequest(ctx pkg.Context, options ...pkg.RequestOption) (
 	for _, v := range options {
 		v(req)
 	}
+	if err = s.bindSession(req); err != nil {
+		s.logger.Error(err)
+		return
+	}
 	return ctx.GetTask().YieldRequest(ctx, req)
 }
+
+// bindSession applies the session before the request is scheduled, so the slot of the session is used.
+func (s *BaseSpider) bindSession(request pkg.Request) (err error) {
+	if request.GetSession() == "" {
+		return
+	}
+	_, err = s.sessions.Bind(request)
+	return
+}
 func (s *BaseSpider) MustNewRequest(ctx pkg.Context, options ...pkg.RequestOption) {
 	if err := s.NewRequest(ctx, options...); err != nil {
 		s.logger.Error(err)
@@ -337,6 +366,51 @@ func (s *BaseSpider) RequestSlotLoad(slot string) (value any, ok bool) {
 func (s *BaseSpider) RequestSlotStore(slot string, value any) {
 	s.requestSlots.Store(slot, value)
 }
+func (s *BaseSpider) RequestSlotLoadOrStore(slot string, value any) (actual any, loaded bool) {
+	return s.requestSlots.LoadOrStore(slot, value)
+}
+func (s *BaseSpider) GetCookieJar(name string) pkg.CookieJar {
+	if name == "" {
+		name = pkg.CookieJarDefault
+	}
+	value, ok := s.cookieJars.Load(name)
+	if !ok {
+		return nil
+	}
+	return value.(pkg.CookieJar)
+}
+func (s *BaseSpider) SetCookieJar(name string, jar pkg.CookieJar) pkg.Spider {
+	if name == "" {
+		name = pkg.CookieJarDefault
+	}
+	s.cookieJars.Store(name, jar)
+	return s
+}
+func (s *BaseSpider) LoadOrStoreCookieJar(name string, jar pkg.CookieJar) pkg.CookieJar {
+	if name == "" {
+		name = pkg.CookieJarDefault
+	}
+	value, _ := s.cookieJars.LoadOrStore(name, jar)
+	return value.(pkg.CookieJar)
+}
+func (s *BaseSpider) CookieJars() (jars map[string]pkg.CookieJar) {
+	jars = make(map[string]pkg.CookieJar)
+	s.cookieJars.Range(func(key, value any) bool {
+		jars[key.(string)] = value.(pkg.CookieJar)
+		return true
+	})
+	return
+}
+func (s *BaseSpider) GetSessions() pkg.Sessions {
+	return s.sessions
+}
+func (s *BaseSpider) SlotType() pkg.SlotType {
+	return s.slotType
+}
+func (s *BaseSpider) SetSlotType(slotType pkg.SlotType) pkg.Spider {
+	s.slotType = slotType
+	return s
+}
 func (s *BaseSpider) SetRequestRate(slot string, interval time.Duration, concurrency int) {
 	if slot == "" {
 		slot = "*"
@@ -359,6 +433,22 @@ func (s *BaseSpider) SetRequestRate(slot string, interval time.Duration, concurr
 
 	return
 }
+func (s *BaseSpider) GetSitemaps() []string {
+	s.sitemapsMutex.RLock()
+	defer s.sitemapsMutex.RUnlock()
+
+	return append([]string{}, s.sitemaps...)
+}
+func (s *BaseSpider) AddSitemaps(sitemaps ...string) {
+	s.sitemapsMutex.Lock()
+	defer s.sitemapsMutex.Unlock()
+
+	for _, v := range sitemaps {
+		if !utils.InSlice(v, s.sitemaps) {
+			s.sitemaps = append(s.sitemaps, v)
+		}
+	}
+}
 func (s *BaseSpider) Start(c pkg.Context) (err error) {
 	ctx := context.Background()
 
@@ -546,12 +636,17 @@ func (s *BaseSpider) FromCrawler(crawler pkg.Crawler) pkg.Spider {
 
 	s.concurrency = config.GetRequestConcurrency()
 	s.interval = time.Millisecond * time.Duration(int(config.GetRequestInterval()))
+	s.slotType = config.GetRequestSlot()
 
 	switch config.GetFilter() {
 	case pkg.FilterMemory:
 		s.SetFilter(new(filters.MemoryFilter).FromSpider(s))
 	case pkg.FilterRedis:
 		s.SetFilter(new(filters.RedisFilter).FromSpider(s))
+	case pkg.FilterBloom:
+		s.SetFilter(new(filters.BloomFilter).FromSpider(s))
+	case pkg.FilterSqlite:
+		s.SetFilter(new(filters.SqliteFilter).FromSpider(s))
 	default:
 	}
 
@@ -588,6 +683,7 @@ func NewBaseSpider(logger pkg.Logger) (pkg.Spider, error) {
 		defaultAllowedDomains: defaultAllowedDomains,
 		allowedDomains:        defaultAllowedDomains,
 		jobs:                  make(map[string]*Job),
+		sessions:              sessions.NewPool(),
 	}
 
 	s.job = pkg.NewState("job")
@@ -137,6 +137,11 @@ func (j *Job) run(ctx context.Context) (err error) {
 		job := new(cron.Job).
 			MustEverySpec(j.context.GetJob().GetSpec()).
 			Callback(func() {
+				// the cron job is scheduled on every node of the cluster, and it fires only on the leader at the time
+				if cluster := j.crawler.GetCluster(); cluster != nil && !cluster.IsLeader() {
+					j.logger.Debug("the node isn't the leader, skip the cron job", j.context.GetJob().GetId())
+					return
+				}
 				if j.context.GetJob().GetOnlyOneTask() {
 					if _, ok := <-j.cronJob; !ok {
 						// closed
@@ -0,0 +1,102 @@
+package spider
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lizongying/go-crawler/pkg"
+	"github.com/lizongying/go-crawler/pkg/request"
+	"github.com/lizongying/go-crawler/pkg/sitemap"
+	"net/url"
+)
+
+// YieldSitemap fetches the sitemaps and yields a request with the callback for every url in them.
+// urls can be sitemaps, sitemap indexes, text sitemaps or robots.txt, gzip compressed or not.
+// Nested sitemaps are followed recursively, errors of a sitemap are logged and joined, the others are still crawled.
+func (s *BaseSpider) YieldSitemap(ctx pkg.Context, callBack pkg.CallBack, urls []string, options ...pkg.SitemapOption) (err error) {
+	sitemapOptions := &pkg.SitemapOptions{
+		MaxDepth: pkg.DefaultSitemapMaxDepth,
+	}
+	for _, option := range options {
+		option(sitemapOptions)
+	}
+
+	visited := make(map[string]struct{})
+	for _, u := range urls {
+		err = errors.Join(err, s.yieldSitemap(ctx, callBack, u, 0, sitemapOptions, visited))
+	}
+	return
+}
+
+func (s *BaseSpider) yieldSitemap(ctx pkg.Context, callBack pkg.CallBack, sitemapUrl string, depth uint8, options *pkg.SitemapOptions, visited map[string]struct{}) (err error) {
+	if _, ok := visited[sitemapUrl]; ok {
+		return
+	}
+	visited[sitemapUrl] = struct{}{}
+
+	response, err := s.Request(ctx, request.NewRequest().SetUrl(sitemapUrl))
+	if err != nil {
+		s.logger.Error(sitemapUrl, err)
+		return
+	}
+	if response == nil || response.GetResponse() == nil {
+		err = fmt.Errorf("sitemap %s: nil response", sitemapUrl)
+		s.logger.Error(err)
+		return
+	}
+
+	result, err := sitemap.Parse(response.BodyBytes())
+	if err != nil {
+		err = fmt.Errorf("sitemap %s: %w", sitemapUrl, err)
+		s.logger.Error(err)
+		return
+	}
+
+	base := response.GetRequest().GetURL()
+	for _, v := range result.Urls {
+		if !options.LastModAfter.IsZero() && !v.LastMod.IsZero() && !v.LastMod.After(options.LastModAfter) {
+			continue
+		}
+		loc := resolveUrl(base, v.Loc)
+		if options.UrlRegexp != nil && !options.UrlRegexp.MatchString(loc) {
+			continue
+		}
+
+		req := request.NewRequest().SetUrl(loc).SetCallBack(callBack)
+		for _, option := range options.RequestOptions {
+			option(req)
+		}
+		if e := s.YieldRequest(ctx, req); e != nil {
+			s.logger.Error(e)
+			err = errors.Join(err, e)
+		}
+	}
+
+	if depth >= options.MaxDepth {
+		if len(result.Sitemaps) > 0 {
+			s.logger.Warn("sitemap max depth reached", sitemapUrl)
+		}
+		return
+	}
+	for _, v := range result.Sitemaps {
+		if !options.LastModAfter.IsZero() && !v.LastMod.IsZero() && !v.LastMod.After(options.LastModAfter) {
+			continue
+		}
+		loc := resolveUrl(base, v.Loc)
+		if options.SitemapRegexp != nil && !options.SitemapRegexp.MatchString(loc) {
+			continue
+		}
+		err = errors.Join(err, s.yieldSitemap(ctx, callBack, loc, depth+1, options, visited))
+	}
+	return
+}
+
+func resolveUrl(base *url.URL, loc string) string {
+	if base == nil {
+		return loc
+	}
+	u, err := url.Parse(loc)
+	if err != nil {
+		return loc
+	}
+	return base.ResolveReference(u).String()
+}
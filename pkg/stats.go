@@ -1,5 +1,7 @@
 package pkg
 
+import "time"
+
 type Stats interface {
 	RequestTotal() uint32
 	IncRequestTotal() uint32
@@ -43,3 +45,26 @@ type StatsWithMedia interface {
 	FileTotal() uint32
 	IncFileTotal() uint32
 }
+
+type StatsWithProxy interface {
+	Stats
+	ProxySuccess(string) uint32
+	IncProxySuccess(string) uint32
+	ProxyFailure(string) uint32
+	IncProxyFailure(string) uint32
+}
+
+type StatsWithThrottle interface {
+	Stats
+	ThrottleDelay(string) time.Duration
+	SetThrottleDelay(string, time.Duration)
+}
+
+type StatsWithQueue interface {
+	Stats
+	RequestInFlight() uint32
+	IncRequestInFlight() uint32
+	DecRequestInFlight() uint32
+	RequestReclaimed() uint32
+	IncRequestReclaimed(uint32) uint32
+}
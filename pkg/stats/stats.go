@@ -1,6 +1,11 @@
 package stats
 
-import "sync/atomic"
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type Stats struct {
 	requestTotal   uint32
@@ -150,8 +155,135 @@ func (s *FileStats) GetMap() map[string]uint32 {
 	}
 }
 
+// ProxyStats counts responses per proxy, keyed by the proxy url.
+type ProxyStats struct {
+	proxySuccess sync.Map
+	proxyFailure sync.Map
+}
+
+func (s *ProxyStats) load(m *sync.Map, proxy string) *uint32 {
+	v, _ := m.LoadOrStore(proxy, new(uint32))
+	return v.(*uint32)
+}
+func (s *ProxyStats) ProxySuccess(proxy string) uint32 {
+	return atomic.LoadUint32(s.load(&s.proxySuccess, proxy))
+}
+func (s *ProxyStats) IncProxySuccess(proxy string) uint32 {
+	return atomic.AddUint32(s.load(&s.proxySuccess, proxy), 1)
+}
+func (s *ProxyStats) ProxyFailure(proxy string) uint32 {
+	return atomic.LoadUint32(s.load(&s.proxyFailure, proxy))
+}
+func (s *ProxyStats) IncProxyFailure(proxy string) uint32 {
+	return atomic.AddUint32(s.load(&s.proxyFailure, proxy), 1)
+}
+
+// addToMap adds the proxy stats to m as "proxySuccess:<proxy>" and "proxyFailure:<proxy>".
+func (s *ProxyStats) addToMap(m map[string]uint32) map[string]uint32 {
+	s.proxySuccess.Range(func(key, value any) bool {
+		m["proxySuccess:"+key.(string)] = atomic.LoadUint32(value.(*uint32))
+		return true
+	})
+	s.proxyFailure.Range(func(key, value any) bool {
+		m["proxyFailure:"+key.(string)] = atomic.LoadUint32(value.(*uint32))
+		return true
+	})
+	return m
+}
+
+// ThrottleStats records the delay of the auto throttle per request slot.
+type ThrottleStats struct {
+	throttleDelay sync.Map
+}
+
+func (s *ThrottleStats) ThrottleDelay(slot string) time.Duration {
+	v, ok := s.throttleDelay.Load(slot)
+	if !ok {
+		return 0
+	}
+	return v.(time.Duration)
+}
+func (s *ThrottleStats) SetThrottleDelay(slot string, delay time.Duration) {
+	s.throttleDelay.Store(slot, delay)
+}
+
+// throttleSlot is the throttle of a request slot in the api.
+type throttleSlot struct {
+	// Delay is in milliseconds
+	Delay int64 `json:"delay"`
+	// Rate is in requests per minute, 0 if the slot isn't limited
+	Rate uint32 `json:"rate"`
+}
+
+func (s *ThrottleStats) slots() map[string]throttleSlot {
+	slots := make(map[string]throttleSlot)
+	s.throttleDelay.Range(func(key, value any) bool {
+		delay := value.(time.Duration)
+		slot := throttleSlot{Delay: delay.Milliseconds()}
+		if delay > 0 {
+			slot.Rate = uint32(time.Minute / delay)
+		}
+		slots[key.(string)] = slot
+		return true
+	})
+	return slots
+}
+
+// addToMap adds the throttle stats to m as "throttleDelay:<slot>" in milliseconds,
+// and "throttleRate:<slot>" in requests per minute.
+func (s *ThrottleStats) addToMap(m map[string]uint32) map[string]uint32 {
+	s.throttleDelay.Range(func(key, value any) bool {
+		delay := value.(time.Duration)
+		m["throttleDelay:"+key.(string)] = uint32(delay.Milliseconds())
+		if delay > 0 {
+			m["throttleRate:"+key.(string)] = uint32(time.Minute / delay)
+		}
+		return true
+	})
+	return m
+}
+
+// QueueStats counts the requests held by the reliable queue of this node.
+// They're added to the map only after the queue has been used.
+type QueueStats struct {
+	used             uint32
+	requestInFlight  uint32
+	requestReclaimed uint32
+}
+
+func (s *QueueStats) RequestInFlight() uint32 {
+	return atomic.LoadUint32(&s.requestInFlight)
+}
+func (s *QueueStats) IncRequestInFlight() uint32 {
+	atomic.StoreUint32(&s.used, 1)
+	return atomic.AddUint32(&s.requestInFlight, 1)
+}
+func (s *QueueStats) DecRequestInFlight() uint32 {
+	return atomic.AddUint32(&s.requestInFlight, ^uint32(0))
+}
+func (s *QueueStats) RequestReclaimed() uint32 {
+	return atomic.LoadUint32(&s.requestReclaimed)
+}
+func (s *QueueStats) IncRequestReclaimed(n uint32) uint32 {
+	atomic.StoreUint32(&s.used, 1)
+	return atomic.AddUint32(&s.requestReclaimed, n)
+}
+
+// addToMap adds the queue stats to m as "requestInFlight" and "requestReclaimed".
+func (s *QueueStats) addToMap(m map[string]uint32) map[string]uint32 {
+	if atomic.LoadUint32(&s.used) == 0 {
+		return m
+	}
+	m["requestInFlight"] = s.RequestInFlight()
+	m["requestReclaimed"] = s.RequestReclaimed()
+	return m
+}
+
 type MediaStats struct {
 	Stats
+	ProxyStats
+	ThrottleStats
+	QueueStats
 	imageTotal uint32
 	fileTotal  uint32
 }
@@ -169,6 +301,9 @@ func (s *MediaStats) IncFileTotal() uint32 {
 	return atomic.AddUint32(&s.fileTotal, 1)
 }
 func (s *MediaStats) GetMap() map[string]uint32 {
+	return s.QueueStats.addToMap(s.ThrottleStats.addToMap(s.ProxyStats.addToMap(s.fields())))
+}
+func (s *MediaStats) fields() map[string]uint32 {
 	return map[string]uint32{
 		"requestTotal":   s.RequestTotal(),
 		"requestSuccess": s.RequestSuccess(),
@@ -184,3 +319,15 @@ func (s *MediaStats) GetMap() map[string]uint32 {
 		"fileTotal":      s.FileTotal(),
 	}
 }
+
+// MarshalJSON makes the stats visible in the api, the throttle of the request slots is under "throttle".
+func (s *MediaStats) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any)
+	for k, v := range s.fields() {
+		m[k] = v
+	}
+	if slots := s.ThrottleStats.slots(); len(slots) > 0 {
+		m["throttle"] = slots
+	}
+	return json.Marshal(m)
+}
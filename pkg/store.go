@@ -1,8 +1,24 @@
 package pkg
 
-import "github.com/aws/aws-sdk-go-v2/service/s3"
+import (
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"io"
+	"os"
+)
 
 type Store interface {
 	S3Client() *s3.Client
 	Save(prefix string, key string, body []byte) (storePath string, err error)
+	// SaveReader saves the body by streaming, the s3 stores use the multipart upload.
+	SaveReader(prefix string, key string, body io.Reader) (storePath string, err error)
+}
+
+// StoreWithPartial keeps the partial files, so the downloads can be resumed by Range.
+type StoreWithPartial interface {
+	// PartialSize returns the size of the partial file, ok is false if the store can't keep partial files.
+	PartialSize(prefix string, name string) (size int64, ok bool)
+	// OpenPartial opens the partial file to read and append, it's created if not exists.
+	OpenPartial(prefix string, name string) (*os.File, error)
+	// CommitPartial moves the completed partial file to the key.
+	CommitPartial(prefix string, name string, key string) (storePath string, err error)
 }
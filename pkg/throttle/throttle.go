@@ -0,0 +1,99 @@
+package throttle
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Throttle adjusts the delay of a request slot by the observed responses.
+// The delay moves toward latency/targetConcurrency, so about targetConcurrency requests are in flight,
+// and it's doubled by 429/503 responses and timeouts.
+type Throttle struct {
+	mutex             sync.Mutex
+	delay             time.Duration
+	minDelay          time.Duration
+	maxDelay          time.Duration
+	targetConcurrency float64
+}
+
+func (t *Throttle) Delay() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.delay
+}
+
+// SetMinDelay sets the minimum delay, e.g. the crawl delay of robots.txt, and raises the delay to it.
+func (t *Throttle) SetMinDelay(minDelay time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.minDelay = minDelay
+	if t.delay < minDelay {
+		t.delay = minDelay
+	}
+}
+
+// Concurrency returns how many requests can start together, it's about latency/delay as the requests in flight.
+// It's targetConcurrency after fast responses, and it shrinks to 1 when the delay is backed off.
+func (t *Throttle) Concurrency(latency time.Duration) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	target := math.Max(1, math.Ceil(t.targetConcurrency))
+	if t.delay <= 0 {
+		return int(target)
+	}
+	return int(math.Max(1, math.Min(target, math.Round(float64(latency)/float64(t.delay)))))
+}
+
+// Observe updates the delay by the latency and the status code of a response, and returns the new delay.
+// timeout is true if the request timed out without a response.
+func (t *Throttle) Observe(latency time.Duration, statusCode int, timeout bool) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var delay time.Duration
+	if timeout || statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable {
+		delay = t.delay * 2
+		if delay < latency {
+			delay = latency
+		}
+		if delay == 0 {
+			delay = time.Second
+		}
+	} else {
+		target := time.Duration(float64(latency) / t.targetConcurrency)
+		delay = (t.delay + target) / 2
+		if delay < target {
+			delay = target
+		}
+		// the error responses may be fast, they shouldn't decrease the delay
+		if (statusCode < http.StatusOK || statusCode >= http.StatusMultipleChoices) && delay < t.delay {
+			delay = t.delay
+		}
+	}
+
+	if delay < t.minDelay {
+		delay = t.minDelay
+	}
+	if t.maxDelay > 0 && delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	t.delay = delay
+	return delay
+}
+
+func NewThrottle(delay time.Duration, minDelay time.Duration, maxDelay time.Duration, targetConcurrency float64) *Throttle {
+	if targetConcurrency <= 0 {
+		targetConcurrency = 1
+	}
+	return &Throttle{
+		delay:             delay,
+		minDelay:          minDelay,
+		maxDelay:          maxDelay,
+		targetConcurrency: targetConcurrency,
+	}
+}
@@ -0,0 +1,70 @@
+package throttle
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	th := NewThrottle(time.Second, 100*time.Millisecond, 10*time.Second, 2)
+
+	// fast responses decrease the delay toward latency/targetConcurrency
+	var delay time.Duration
+	for i := 0; i < 20; i++ {
+		delay = th.Observe(400*time.Millisecond, http.StatusOK, false)
+	}
+	if delay < 200*time.Millisecond || delay > 201*time.Millisecond {
+		t.Errorf("got %v, want 200ms", delay)
+	}
+
+	// fast error responses don't decrease it
+	if d := th.Observe(time.Millisecond, http.StatusNotFound, false); d != delay {
+		t.Errorf("got %v, want %v", d, delay)
+	}
+
+	if d := th.Observe(time.Millisecond, http.StatusTooManyRequests, false); d != delay*2 {
+		t.Errorf("got %v, want %v", d, delay*2)
+	}
+	for i := 0; i < 10; i++ {
+		delay = th.Observe(0, 0, true)
+	}
+	if delay != 10*time.Second {
+		t.Errorf("got %v, want the max delay", delay)
+	}
+
+	for i := 0; i < 50; i++ {
+		delay = th.Observe(0, http.StatusOK, false)
+	}
+	if delay != 100*time.Millisecond {
+		t.Errorf("got %v, want the min delay", delay)
+	}
+}
+
+func TestThrottle_Concurrency(t *testing.T) {
+	th := NewThrottle(0, 0, 10*time.Second, 3)
+	if c := th.Concurrency(time.Second); c != 3 {
+		t.Errorf("got %d, want the target concurrency", c)
+	}
+
+	for i := 0; i < 20; i++ {
+		th.Observe(time.Second, http.StatusOK, false)
+	}
+	if c := th.Concurrency(time.Second); c != 3 {
+		t.Errorf("got %d, want 3", c)
+	}
+
+	// the backed off delay lets only one request start
+	th.Observe(time.Second, http.StatusServiceUnavailable, false)
+	if c := th.Concurrency(time.Second); c != 1 {
+		t.Errorf("got %d, want 1", c)
+	}
+
+	// the min delay, e.g. the crawl delay, is a floor
+	th.SetMinDelay(5 * time.Second)
+	for i := 0; i < 20; i++ {
+		if d := th.Observe(10*time.Millisecond, http.StatusOK, false); d != 5*time.Second {
+			t.Fatalf("got %v, want the min delay", d)
+		}
+	}
+}